@@ -5,10 +5,21 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sstreichan/logcleaner/internal/cli"
+	"github.com/sstreichan/logcleaner/internal/manifest"
 	"github.com/sstreichan/logcleaner/internal/tui"
 )
 
+// version is set by release builds with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
+	manifest.Version = version
+
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	model, err := tui.NewModel()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
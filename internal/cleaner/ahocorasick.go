@@ -0,0 +1,75 @@
+package cleaner
+
+// ahoCorasick is a byte-oriented Aho-Corasick automaton compiled to a
+// dense transition table over the byte classes used by its patterns
+type ahoCorasick struct {
+	classes [256]uint16
+	nclass  int
+	delta   []int32
+	out     [][]int32
+}
+
+func newAhoCorasick(patterns []string) *ahoCorasick {
+	ac := &ahoCorasick{nclass: 1}
+
+	// Bytes that never occur in a pattern share class 0 and lead to the root
+	for _, p := range patterns {
+		for i := 0; i < len(p); i++ {
+			if ac.classes[p[i]] == 0 {
+				ac.classes[p[i]] = uint16(ac.nclass)
+				ac.nclass++
+			}
+		}
+	}
+
+	// Build the trie, using -1 for missing edges
+	ac.addState()
+	for id, p := range patterns {
+		state := int32(0)
+		for i := 0; i < len(p); i++ {
+			idx := int(state)*ac.nclass + int(ac.classes[p[i]])
+			if ac.delta[idx] < 0 {
+				ac.delta[idx] = ac.addState()
+			}
+			state = ac.delta[idx]
+		}
+		ac.out[state] = append(ac.out[state], int32(id))
+	}
+
+	// Breadth-first pass to resolve failure links into full transitions
+	fail := make([]int32, len(ac.out))
+	queue := make([]int32, 0, len(ac.out))
+	for c := 0; c < ac.nclass; c++ {
+		if next := ac.delta[c]; next < 0 {
+			ac.delta[c] = 0
+		} else {
+			queue = append(queue, next)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		ac.out[state] = append(ac.out[state], ac.out[fail[state]]...)
+
+		for c := 0; c < ac.nclass; c++ {
+			idx := int(state)*ac.nclass + c
+			fallback := ac.delta[int(fail[state])*ac.nclass+c]
+			if next := ac.delta[idx]; next < 0 {
+				ac.delta[idx] = fallback
+			} else {
+				fail[next] = fallback
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return ac
+}
+
+func (ac *ahoCorasick) addState() int32 {
+	for i := 0; i < ac.nclass; i++ {
+		ac.delta = append(ac.delta, -1)
+	}
+	ac.out = append(ac.out, nil)
+	return int32(len(ac.out) - 1)
+}
@@ -0,0 +1,54 @@
+package cleaner
+
+import "github.com/sstreichan/logcleaner/internal/textenc"
+
+// FileResult is the outcome of cleaning one file of a batch
+type FileResult struct {
+	InputPath  string
+	OutputPath string
+	Stats      *Stats
+	Err        error
+}
+
+// BatchResult holds the per-file results of CleanFiles in input order and
+// the stats of all successfully cleaned files combined
+type BatchResult struct {
+	Files []FileResult
+	Total Stats
+}
+
+// Failed returns the results of the files that could not be cleaned
+func (r *BatchResult) Failed() []FileResult {
+	var failed []FileResult
+	for _, f := range r.Files {
+		if f.Err != nil {
+			failed = append(failed, f)
+		}
+	}
+	return failed
+}
+
+// CleanFiles cleans each input into the path returned by outputPath. A file
+// that fails is recorded and the batch continues with the next one.
+// progressCb is called after each file with the number of files done.
+func (c *Cleaner) CleanFiles(inputs []string, outputPath func(string) string, progressCb func(done, total int)) *BatchResult {
+	result := &BatchResult{
+		Files: make([]FileResult, 0, len(inputs)),
+		Total: *c.newStats(textenc.Detection{}),
+	}
+
+	for i, input := range inputs {
+		res := FileResult{InputPath: input, OutputPath: outputPath(input)}
+		res.Stats, res.Err = c.Clean(input, res.OutputPath, nil)
+		if res.Err == nil {
+			result.Total.add(res.Stats)
+		}
+		result.Files = append(result.Files, res)
+
+		if progressCb != nil {
+			progressCb(i+1, len(inputs))
+		}
+	}
+
+	return result
+}
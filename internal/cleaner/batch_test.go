@@ -0,0 +1,57 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestCleanFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	first := filepath.Join(tempDir, "a.log")
+	missing := filepath.Join(tempDir, "missing.log")
+	second := filepath.Join(tempDir, "b.log")
+
+	if err := os.WriteFile(first, []byte("INFO: a\nERROR: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("ERROR: c\nINFO: d\nINFO: e\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+	c := New([]*filter.Filter{f})
+
+	var progress []int
+	result := c.CleanFiles([]string{first, missing, second}, func(p string) string { return p + ".cleaned" }, func(done, total int) {
+		if total != 3 {
+			t.Errorf("progress total = %d, want 3", total)
+		}
+		progress = append(progress, done)
+	})
+
+	if len(result.Files) != 3 || len(progress) != 3 {
+		t.Fatalf("got %d results and %d progress calls, want 3 each", len(result.Files), len(progress))
+	}
+
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].InputPath != missing {
+		t.Errorf("Failed() = %+v, want only %s", failed, missing)
+	}
+
+	if result.Files[2].Stats.TotalLines != 3 || result.Files[2].OutputPath != second+".cleaned" {
+		t.Errorf("unexpected result for %s: %+v", second, result.Files[2])
+	}
+
+	total := result.Total
+	if total.TotalLines != 5 || total.FilteredLines != 2 || total.FilterHits[0] != 2 {
+		t.Errorf("unexpected total stats %+v", total)
+	}
+
+	got, _ := os.ReadFile(second + ".cleaned")
+	if string(got) != "INFO: d\nINFO: e\n" {
+		t.Errorf("output = %q", got)
+	}
+}
@@ -3,23 +3,247 @@ package cleaner
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/sstreichan/logcleaner/internal/countmin"
+	"github.com/sstreichan/logcleaner/internal/drain"
 	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/histogram"
+	"github.com/sstreichan/logcleaner/internal/normalize"
+	"github.com/sstreichan/logcleaner/internal/textenc"
 )
 
+const (
+	defaultMaxLineLength = 1024 * 1024
+	defaultChunkSize     = 4 * 1024 * 1024
+)
+
+// LongLinePolicy decides what happens to lines longer than MaxLineLength.
+// Filters only ever see the first MaxLineLength bytes of such a line.
+type LongLinePolicy string
+
+const (
+	LongLinePassThrough LongLinePolicy = "pass"
+	LongLineTruncate    LongLinePolicy = "truncate"
+	LongLineDrop        LongLinePolicy = "drop"
+)
+
+// Options tune how a Cleaner processes its input
+type Options struct {
+	// Workers > 1 filters newline-aligned chunks on a pool of goroutines,
+	// unless rate-limited sample filters need the lines in order
+	Workers int
+	// ChunkSize is the target size in bytes of a parallel work unit
+	ChunkSize int
+
+	// MaxLineLength is the longest line content in bytes that is buffered
+	// in full; it defaults to 1 MiB
+	MaxLineLength int
+	// LongLines defaults to LongLinePassThrough
+	LongLines LongLinePolicy
+
+	// Encoding overrides the detected input encoding. Input is always
+	// filtered as UTF-8; PreserveEncoding converts the output back.
+	Encoding         textenc.Encoding
+	PreserveEncoding bool
+
+	// DedupWindow > 0 collapses a kept line repeated before DedupWindow
+	// newer distinct lines have been kept into its first occurrence, marked
+	// " [repeated N times]"; 1 collapses consecutive repeats only. Lines
+	// are compared with timestamps, numbers, UUIDs, hex strings and IPs
+	// normalized unless DedupExact is set.
+	DedupWindow int
+	DedupExact  bool
+
+	// Templates > 0 groups the kept lines into message templates and
+	// reports the Templates largest in Stats. Follow does not mine
+	// templates.
+	Templates int
+
+	// Histogram records lines per time bucket and severity level, in total
+	// and kept by the filters, in Stats.Histogram. Follow does not record
+	// it.
+	Histogram bool
+
+	// RemovedSamples > 0 keeps the first RemovedSamples lines each filter
+	// removed in Stats.Removed. Follow does not keep them.
+	RemovedSamples int
+}
+
 type Cleaner struct {
 	filters []*filter.Filter
+	opts    Options
+	matcher *matcher
+	scratch *matchScratch
+
+	// counting are the indexes of the frequency and sample filters, which
+	// keep applies after all others
+	counting []int
+
+	// shapes counts line shapes for the frequency filters during a run
+	frequency   bool
+	shapes      *countmin.Sketch
+	countOnline bool
+
+	// limiter tracks the rate-limited sample filters during a run
+	rateLimited bool
+	limiter     *limiter
 }
 
 func New(filters []*filter.Filter) *Cleaner {
-	return &Cleaner{filters: filters}
+	return NewWithOptions(filters, Options{})
+}
+
+func NewWithOptions(filters []*filter.Filter, opts Options) *Cleaner {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = defaultChunkSize
+	}
+	if opts.MaxLineLength <= 0 {
+		opts.MaxLineLength = defaultMaxLineLength
+	}
+	if opts.LongLines == "" {
+		opts.LongLines = LongLinePassThrough
+	}
+
+	m := newMatcher(filters)
+	c := &Cleaner{filters: filters, opts: opts, matcher: m, scratch: m.newScratch()}
+	for i, f := range filters {
+		switch f.Type {
+		case filter.TypeFrequency:
+			c.frequency = true
+		case filter.TypeSample:
+			c.rateLimited = c.rateLimited || f.RateLimited()
+		default:
+			continue
+		}
+		c.counting = append(c.counting, i)
+	}
+	return c
 }
 
 type Stats struct {
 	TotalLines    int
 	FilteredLines int
 	BytesRead     int64
+
+	// Lines longer than MaxLineLength, and how many of them were
+	// truncated or dropped by the long line policy
+	LongLines        int
+	TruncatedLines   int
+	DroppedLongLines int
+
+	// OverlapLines were skipped as duplicates where rotated files overlap
+	OverlapLines int
+
+	// DuplicateLines were kept by the filters but collapsed as repeats
+	DuplicateLines int
+
+	// Templates are the most frequent shapes of the kept lines, counted
+	// before repeats are collapsed; see Options.Templates
+	Templates []drain.Template
+
+	// Suppressed counts, per line shape, the lines removed by frequency
+	// filters; at most 1000 shapes are listed
+	Suppressed map[string]int
+
+	// SampledOut are the lines removed by sample filters; they are
+	// included in FilteredLines
+	SampledOut int
+
+	// Histogram counts lines over time and by level; see Options.Histogram
+	Histogram *histogram.Histogram
+
+	// Removed holds, per filter index, samples of the lines the filter
+	// removed in input order, cut to 200 bytes; see Options.RemovedSamples
+	Removed      [][]string
+	removedLimit int
+
+	// FilterHits counts, per filter index, the lines each filter removed.
+	// A line is attributed to the first filter in order that rejects it.
+	FilterHits []int
+
+	// Encoding is the input encoding the run was decoded from
+	Encoding textenc.Detection
+}
+
+// RemainingLines returns the number of lines written to the output
+func (s *Stats) RemainingLines() int {
+	return s.TotalLines - s.FilteredLines - s.DroppedLongLines - s.DuplicateLines
+}
+
+func (s *Stats) add(o *Stats) {
+	s.TotalLines += o.TotalLines
+	s.FilteredLines += o.FilteredLines
+	s.BytesRead += o.BytesRead
+	s.LongLines += o.LongLines
+	s.TruncatedLines += o.TruncatedLines
+	s.DroppedLongLines += o.DroppedLongLines
+	s.OverlapLines += o.OverlapLines
+	s.DuplicateLines += o.DuplicateLines
+	s.SampledOut += o.SampledOut
+	for i, hits := range o.FilterHits {
+		s.addHits(i, hits)
+	}
+	for shape, count := range o.Suppressed {
+		s.addSuppressed(shape, count)
+	}
+	for i, lines := range o.Removed {
+		for _, line := range lines {
+			s.addRemoved(i, line)
+		}
+	}
+	if o.Histogram != nil {
+		if s.Histogram == nil {
+			s.Histogram = histogram.New()
+		}
+		s.Histogram.Merge(o.Histogram)
+	}
+}
+
+func (s *Stats) addHits(filter, hits int) {
+	for len(s.FilterHits) <= filter {
+		s.FilterHits = append(s.FilterHits, 0)
+	}
+	s.FilterHits[filter] += hits
+}
+
+// newStats returns the Stats of a run reading input in detection
+func (c *Cleaner) newStats(detection textenc.Detection) *Stats {
+	stats := &Stats{Encoding: detection, FilterHits: make([]int, len(c.filters))}
+	if c.opts.Histogram {
+		stats.Histogram = histogram.New()
+	}
+	stats.removedLimit = c.opts.RemovedSamples
+	return stats
+}
+
+// record counts line in the histogram, if there is one
+func (s *Stats) record(line []byte, kept bool) {
+	if s.Histogram != nil {
+		s.Histogram.Add(line, kept)
+	}
+}
+
+// Snapshot returns a copy of s that shares no state with it
+func (s *Stats) Snapshot() Stats {
+	snapshot := *s
+	snapshot.FilterHits = append([]int(nil), s.FilterHits...)
+	snapshot.Removed = nil
+	for _, lines := range s.Removed {
+		snapshot.Removed = append(snapshot.Removed, append([]string(nil), lines...))
+	}
+	if s.Histogram != nil {
+		snapshot.Histogram = s.Histogram.Clone()
+	}
+	if s.Suppressed != nil {
+		snapshot.Suppressed = make(map[string]int, len(s.Suppressed))
+		for shape, count := range s.Suppressed {
+			snapshot.Suppressed[shape] = count
+		}
+	}
+	return snapshot
 }
 
 func (c *Cleaner) Clean(inputPath, outputPath string, progressCb func(int, int)) (*Stats, error) {
@@ -35,58 +259,324 @@ func (c *Cleaner) Clean(inputPath, outputPath string, progressCb func(int, int))
 	}
 	defer outFile.Close()
 
-	stats := &Stats{}
-	scanner := bufio.NewScanner(inFile)
-	writer := bufio.NewWriter(outFile)
-	defer writer.Flush()
+	return c.cleanFile(inFile, outFile, progressCb)
+}
+
+// CleanTo is like Clean but writes the cleaned output to w
+func (c *Cleaner) CleanTo(inputPath string, w io.Writer, progressCb func(int, int)) (*Stats, error) {
+	inFile, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer inFile.Close()
 
-	// Increase buffer size for large lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	return c.cleanFile(inFile, w, progressCb)
+}
 
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		stats.TotalLines++
-		stats.BytesRead += int64(len(line))
+func (c *Cleaner) cleanFile(inFile *os.File, w io.Writer, progressCb func(int, int)) (*Stats, error) {
+	detection, err := c.detectEncoding(inFile.Name())
+	if err != nil {
+		return nil, err
+	}
 
-		if progressCb != nil && lineNum%1000 == 0 {
-			progressCb(lineNum, stats.FilteredLines)
+	if c.beginShapes(false) {
+		defer c.endShapes()
+		if err := c.countShapes(textenc.NewDecoder(inFile, detection)); err != nil {
+			return nil, err
 		}
+		if _, err := inFile.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind input file: %w", err)
+		}
+	}
+
+	return c.cleanStream(textenc.NewDecoder(inFile, detection), detection, w, progressCb)
+}
+
+// cleanStream filters UTF-8 input decoded from detection to w
+func (c *Cleaner) cleanStream(input io.Reader, detection textenc.Detection, w io.Writer, progressCb func(int, int)) (*Stats, error) {
+	var err error
+	stats := c.newStats(detection)
 
-		shouldKeep := c.shouldKeepLine(line)
-		if shouldKeep {
-			if _, err := writer.WriteString(line + "\n"); err != nil {
-				return stats, fmt.Errorf("failed to write line: %w", err)
+	var output io.Writer = w
+	var encoder io.WriteCloser
+	if c.opts.PreserveEncoding {
+		encoder = textenc.NewEncoder(w, detection)
+		output = encoder
+	}
+	writer := bufio.NewWriter(output)
+	dedup := c.newDeduper(writer, stats)
+	miner := c.newTemplateMiner(dedup)
+
+	c.beginSampling()
+	defer c.endSampling()
+
+	if c.opts.Workers > 1 && !c.rateLimited {
+		err = c.cleanParallel(input, miner, stats, progressCb)
+	} else {
+		err = c.cleanSequential(input, miner, stats, progressCb)
+	}
+	stats.Templates = miner.top(c.opts.Templates)
+
+	if dedupErr := dedup.Flush(); err == nil && dedupErr != nil {
+		err = fmt.Errorf("failed to write output: %w", dedupErr)
+	}
+	if flushErr := writer.Flush(); err == nil && flushErr != nil {
+		err = fmt.Errorf("failed to write output: %w", flushErr)
+	}
+	if encoder != nil {
+		if closeErr := encoder.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write output: %w", closeErr)
+		}
+	}
+
+	return stats, err
+}
+
+// detectEncoding returns the input encoding, honouring Options.Encoding
+func (c *Cleaner) detectEncoding(inputPath string) (textenc.Detection, error) {
+	detection, err := textenc.DetectFile(inputPath)
+	if err != nil {
+		return detection, fmt.Errorf("failed to detect encoding: %w", err)
+	}
+
+	return c.overrideEncoding(detection), nil
+}
+
+func (c *Cleaner) overrideEncoding(detection textenc.Detection) textenc.Detection {
+	if c.opts.Encoding != "" && c.opts.Encoding != detection.Encoding {
+		detection = textenc.Detection{Encoding: c.opts.Encoding}
+	}
+	return detection
+}
+
+// newLineReader returns a reader whose buffer holds any line of up to
+// MaxLineLength bytes including a CRLF terminator
+func (c *Cleaner) newLineReader(r io.Reader) *bufio.Reader {
+	return bufio.NewReaderSize(r, c.opts.MaxLineLength+2)
+}
+
+func (c *Cleaner) cleanSequential(r io.Reader, writer io.Writer, stats *Stats, progressCb func(int, int)) error {
+	reader := c.newLineReader(r)
+
+	for {
+		raw, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			if err := c.streamLongLine(reader, raw, writer, stats); err != nil {
+				return err
+			}
+			// Long lines are few but slow to read, so each one is reported
+			if progressCb != nil {
+				progressCb(stats.TotalLines, stats.FilteredLines)
 			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+
+		if len(raw) > 0 {
+			if err := c.filterLine(raw, c.scratch, stats, writer); err != nil {
+				return err
+			}
+
+			if progressCb != nil && stats.TotalLines%1000 == 0 {
+				progressCb(stats.TotalLines, stats.FilteredLines)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// filterLine applies the filter set and the long line policy to a complete
+// raw line and writes it to w if it is kept
+func (c *Cleaner) filterLine(raw []byte, scratch *matchScratch, stats *Stats, w io.Writer) error {
+	stats.TotalLines++
+	stats.BytesRead += int64(len(raw))
+
+	content := lineContent(raw)
+	long := len(content) > c.opts.MaxLineLength
+	if long {
+		stats.LongLines++
+		content = content[:c.opts.MaxLineLength]
+		if c.opts.LongLines == LongLineDrop {
+			stats.DroppedLongLines++
+			stats.record(content, false)
+			return nil
+		}
+	}
+
+	keep, by := c.keep(content, scratch)
+	stats.record(content, keep)
+	if !keep {
+		c.reject(by, content, scratch, stats)
+		return nil
+	}
+
+	if long && c.opts.LongLines == LongLineTruncate {
+		stats.TruncatedLines++
+		terminator := raw[len(lineContent(raw)):]
+		return writeAll(w, content, truncationMarker(len(raw)-len(terminator)-len(content)), terminator)
+	}
+
+	return writeAll(w, raw)
+}
+
+// keep applies the filter set to line and reports the index of the
+// rejecting filter. Frequency and sample filters are applied after all
+// others, in their order.
+func (c *Cleaner) keep(line []byte, s *matchScratch) (bool, int) {
+	var count uint32
+	if c.shapes != nil || c.limiter != nil {
+		s.key = normalize.Append(s.key[:0], line)
+	}
+	if c.shapes != nil {
+		if c.countOnline {
+			count = c.shapes.Add(s.key)
 		} else {
-			stats.FilteredLines++
+			count = c.shapes.Count(s.key)
 		}
 	}
+	if c.limiter != nil {
+		c.limiter.observe(line)
+	}
 
-	if err := scanner.Err(); err != nil {
-		return stats, fmt.Errorf("error reading file: %w", err)
+	if keep, by := c.matcher.keep(line, s); !keep {
+		return false, by
 	}
 
-	return stats, nil
+	for _, fi := range c.counting {
+		f := c.filters[fi]
+		if !f.Selects(line) {
+			continue
+		}
+		switch {
+		case f.Type == filter.TypeFrequency:
+			if count > uint32(f.Threshold) {
+				return false, fi
+			}
+		case f.RateLimited():
+			if c.limiter != nil && !c.limiter.allow(fi, f.Limit, time.Duration(f.Interval), s.key) {
+				return false, fi
+			}
+		case f.Rate > 1:
+			if !sampled(line, f.Rate) {
+				return false, fi
+			}
+		}
+	}
+	return true, -1
+}
+
+// reject counts a line removed by filter by
+func (c *Cleaner) reject(by int, line []byte, s *matchScratch, stats *Stats) {
+	stats.FilteredLines++
+	stats.addHits(by, 1)
+	if stats.removedLimit > 0 {
+		stats.addRemoved(by, sampleText(line))
+	}
+	switch c.filters[by].Type {
+	case filter.TypeFrequency:
+		stats.addSuppressed(string(s.key), 1)
+	case filter.TypeSample:
+		stats.SampledOut++
+	}
 }
 
-func (c *Cleaner) shouldKeepLine(line string) bool {
-	if len(c.filters) == 0 {
-		return true
+// streamLongLine handles a line that does not fit into the reader's buffer.
+// first holds its beginning; the rest is read from reader piece by piece so
+// the line never has to be held in memory as a whole.
+func (c *Cleaner) streamLongLine(reader *bufio.Reader, first []byte, w io.Writer, stats *Stats) error {
+	stats.TotalLines++
+	stats.LongLines++
+
+	prefix := first[:c.opts.MaxLineLength]
+	write := false
+	if c.opts.LongLines == LongLineDrop {
+		stats.DroppedLongLines++
+	} else if keep, by := c.keep(prefix, c.scratch); !keep {
+		c.reject(by, prefix, c.scratch, stats)
+	} else {
+		write = true
+	}
+	stats.record(prefix, write)
+
+	passThrough := write && c.opts.LongLines == LongLinePassThrough
+	if write {
+		out := prefix
+		if passThrough {
+			out = first
+		}
+		if err := writeAll(w, out); err != nil {
+			return err
+		}
 	}
 
-	for _, f := range c.filters {
-		matches := f.Matches(line)
+	total := len(first)
+	tail := appendTail(nil, first)
+	for {
+		piece, err := reader.ReadSlice('\n')
+		total += len(piece)
+		tail = appendTail(tail, piece)
+		if passThrough {
+			if err := writeAll(w, piece); err != nil {
+				return err
+			}
+		}
 
-		if f.Type == filter.TypeRemove && matches {
-			return false
+		if err == bufio.ErrBufferFull {
+			continue
 		}
-		if f.Type == filter.TypeKeep && !matches {
-			return false
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading file: %w", err)
 		}
+		break
+	}
+	stats.BytesRead += int64(total)
+
+	if write && !passThrough {
+		stats.TruncatedLines++
+		terminator := tail[len(lineContent(tail)):]
+		return writeAll(w, truncationMarker(total-len(terminator)-len(prefix)), terminator)
 	}
 
-	return true
+	return nil
+}
+
+func truncationMarker(truncated int) []byte {
+	return fmt.Appendf(nil, " [truncated %d bytes]", truncated)
+}
+
+// appendTail keeps the last two bytes seen, enough to detect a CRLF
+func appendTail(tail, data []byte) []byte {
+	if len(data) > 2 {
+		data = data[len(data)-2:]
+	}
+	tail = append(tail, data...)
+	if len(tail) > 2 {
+		tail = append(tail[:0], tail[len(tail)-2:]...)
+	}
+	return tail
+}
+
+func writeAll(w io.Writer, parts ...[]byte) error {
+	for _, part := range parts {
+		if _, err := w.Write(part); err != nil {
+			return fmt.Errorf("failed to write line: %w", err)
+		}
+	}
+	return nil
+}
+
+// lineContent strips the LF or CRLF terminator from a raw line
+func lineContent(raw []byte) []byte {
+	if n := len(raw); n > 0 && raw[n-1] == '\n' {
+		raw = raw[:n-1]
+		if n := len(raw); n > 0 && raw[n-1] == '\r' {
+			raw = raw[:n-1]
+		}
+	}
+	return raw
 }
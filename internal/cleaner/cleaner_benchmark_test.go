@@ -77,6 +77,69 @@ func BenchmarkClean_MultipleFilters(b *testing.B) {
 	}
 }
 
+func BenchmarkClean_FilterScaling(b *testing.B) {
+	tempDir := b.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+	outputPath := filepath.Join(tempDir, "output.log")
+
+	input := generateLogLines(10000)
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, count := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("filters=%d", count), func(b *testing.B) {
+			c := New(generateFilters(count))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := c.Clean(inputPath, outputPath, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkClean_Parallel(b *testing.B) {
+	tempDir := b.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+	outputPath := filepath.Join(tempDir, "output.log")
+
+	input := generateLogLines(10000)
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	filters := generateFilters(100)
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			c := NewWithOptions(filters, Options{Workers: workers, ChunkSize: 64 * 1024})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := c.Clean(inputPath, outputPath, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// generateFilters returns count remove filters, each matching a single
+// line number as produced by generateLogLines
+func generateFilters(count int) []*filter.Filter {
+	filters := make([]*filter.Filter, 0, count)
+	for i := 0; i < count; i++ {
+		pattern := fmt.Sprintf(`line number %d with \w+`, i*7)
+		f, _ := filter.New(fmt.Sprintf("remove-%d", i), pattern, filter.TypeRemove)
+		filters = append(filters, f)
+	}
+	return filters
+}
+
 func generateLogLines(count int) string {
 	levels := []string{"INFO", "DEBUG", "WARN", "ERROR", "TRACE"}
 	var result string
@@ -1,12 +1,18 @@
 package cleaner
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
 )
 
 func TestClean(t *testing.T) {
@@ -40,6 +46,10 @@ DEBUG: debug message
 		t.Errorf("Expected 2 filtered lines, got %d", stats.FilteredLines)
 	}
 
+	if len(stats.FilterHits) != 1 || stats.FilterHits[0] != 2 {
+		t.Errorf("Expected 2 hits for the filter, got %v", stats.FilterHits)
+	}
+
 	output, err := os.ReadFile(outputPath)
 	if err != nil {
 		t.Fatal(err)
@@ -49,3 +59,185 @@ DEBUG: debug message
 		t.Error("Output should not contain ERROR lines")
 	}
 }
+
+func TestClean_PreservesBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			"windows line endings",
+			"INFO: start\r\nERROR: boom\r\nINFO: done\r\n",
+			"INFO: start\r\nINFO: done\r\n",
+		},
+		{
+			"missing final newline",
+			"INFO: start\nERROR: boom\nINFO: done",
+			"INFO: start\nINFO: done",
+		},
+		{
+			"mixed endings and raw bytes",
+			"INFO: Größe \xff\xfe raw\r\nERROR: boom\nINFO: lone \r inside\n\n",
+			"INFO: Größe \xff\xfe raw\r\nINFO: lone \r inside\n\n",
+		},
+	}
+
+	f, _ := filter.New("remove-errors", "^ERROR: boom$", filter.TypeRemove)
+
+	for _, tt := range tests {
+		for _, workers := range []int{1, 3} {
+			t.Run(fmt.Sprintf("%s/workers=%d", tt.name, workers), func(t *testing.T) {
+				tempDir := t.TempDir()
+				inputPath := filepath.Join(tempDir, "input.log")
+				outputPath := filepath.Join(tempDir, "output.log")
+				if err := os.WriteFile(inputPath, []byte(tt.input), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				c := NewWithOptions([]*filter.Filter{f}, Options{Workers: workers, ChunkSize: 8})
+				stats, err := c.Clean(inputPath, outputPath, nil)
+				if err != nil {
+					t.Fatalf("Clean() error = %v", err)
+				}
+
+				output, _ := os.ReadFile(outputPath)
+				if string(output) != tt.want {
+					t.Errorf("Expected output %q, got %q", tt.want, output)
+				}
+
+				if stats.BytesRead != int64(len(tt.input)) {
+					t.Errorf("Expected %d bytes read, got %d", len(tt.input), stats.BytesRead)
+				}
+			})
+		}
+	}
+}
+
+func TestClean_LongLines(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	input := "INFO short\r\n" +
+		"INFO " + long + "\r\n" +
+		"ERROR " + long + "\n" +
+		"INFO tail " + long
+
+	tests := []struct {
+		policy    LongLinePolicy
+		want      string
+		truncated int
+		dropped   int
+	}{
+		{
+			LongLinePassThrough,
+			"INFO short\r\nINFO " + long + "\r\nINFO tail " + long,
+			0, 0,
+		},
+		{
+			LongLineTruncate,
+			"INFO short\r\nINFO xxxxxxxxxxx [truncated 29 bytes]\r\nINFO tail xxxxxx [truncated 34 bytes]",
+			2, 0,
+		},
+		{
+			LongLineDrop,
+			"INFO short\r\n",
+			0, 3,
+		},
+	}
+
+	f, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+
+	for _, tt := range tests {
+		for _, opts := range []Options{
+			{Workers: 1},
+			{Workers: 3, ChunkSize: 8},
+			{Workers: 3, ChunkSize: 256},
+		} {
+			opts.MaxLineLength = 16
+			opts.LongLines = tt.policy
+
+			t.Run(fmt.Sprintf("%s/workers=%d/chunk=%d", tt.policy, opts.Workers, opts.ChunkSize), func(t *testing.T) {
+				tempDir := t.TempDir()
+				inputPath := filepath.Join(tempDir, "input.log")
+				outputPath := filepath.Join(tempDir, "output.log")
+				if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				stats, err := NewWithOptions([]*filter.Filter{f}, opts).Clean(inputPath, outputPath, nil)
+				if err != nil {
+					t.Fatalf("Clean() error = %v", err)
+				}
+
+				output, _ := os.ReadFile(outputPath)
+				if string(output) != tt.want {
+					t.Errorf("Expected output %q, got %q", tt.want, output)
+				}
+
+				if stats.TotalLines != 4 || stats.LongLines != 3 || stats.BytesRead != int64(len(input)) {
+					t.Errorf("Unexpected stats: %+v", *stats)
+				}
+				if stats.TruncatedLines != tt.truncated || stats.DroppedLongLines != tt.dropped {
+					t.Errorf("Expected %d truncated and %d dropped, got %+v", tt.truncated, tt.dropped, *stats)
+				}
+			})
+		}
+	}
+}
+
+func TestClean_LongLineProgress(t *testing.T) {
+	input := strings.Repeat(strings.Repeat("x", 100)+"\n", 3)
+
+	for _, policy := range []LongLinePolicy{LongLinePassThrough, LongLineTruncate} {
+		var reported []int
+		c := NewWithOptions(nil, Options{Workers: 1, MaxLineLength: 16, LongLines: policy})
+		_, err := c.cleanStream(strings.NewReader(input), textenc.Detection{}, io.Discard, func(lines, filtered int) {
+			reported = append(reported, lines)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{1, 2, 3}; !reflect.DeepEqual(reported, want) {
+			t.Errorf("%s: progress = %v, want %v", policy, reported, want)
+		}
+	}
+}
+
+func TestClean_TranscodesInput(t *testing.T) {
+	input := "INFO: Größe\r\nERROR: Fehler\r\n"
+	var raw []byte
+	raw = append(raw, 0xFF, 0xFE)
+	for _, u := range utf16.Encode([]rune(input)) {
+		raw = append(raw, byte(u), byte(u>>8))
+	}
+
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+	if err := os.WriteFile(inputPath, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+
+	outputPath := filepath.Join(tempDir, "output.log")
+	stats, err := New([]*filter.Filter{f}).Clean(inputPath, outputPath, nil)
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if stats.Encoding.Encoding != textenc.UTF16LE || stats.FilteredLines != 1 {
+		t.Errorf("Unexpected stats: %+v", *stats)
+	}
+	output, _ := os.ReadFile(outputPath)
+	if string(output) != "INFO: Größe\r\n" {
+		t.Errorf("Expected UTF-8 output, got %q", output)
+	}
+
+	preservedPath := filepath.Join(tempDir, "preserved.log")
+	c := NewWithOptions([]*filter.Filter{f}, Options{PreserveEncoding: true})
+	if _, err := c.Clean(inputPath, preservedPath, nil); err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	preserved, _ := os.ReadFile(preservedPath)
+	if want := raw[:2+2*len([]rune("INFO: Größe\r\n"))]; !bytes.Equal(preserved, want) {
+		t.Errorf("Expected UTF-16LE output %x, got %x", want, preserved)
+	}
+}
@@ -0,0 +1,189 @@
+package cleaner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/sstreichan/logcleaner/internal/normalize"
+)
+
+// deduper collapses repeated kept lines. Lines are held back until
+// DedupWindow newer distinct lines have been kept; repeats arriving in the
+// meantime are counted and the first occurrence is written with a
+// " [repeated N times]" marker. With deduplication off it passes writes
+// through.
+//
+// A line longer than MaxLineLength is never held back: it flushes the held
+// lines and is passed through as it is written, whether it arrives whole or
+// in pieces, so the output does not depend on how the line was read.
+type deduper struct {
+	w      io.Writer
+	window int
+	exact  bool
+	limit  int
+	stats  *Stats
+
+	held    []*heldLine
+	index   map[string]*heldLine
+	partial []byte
+	long    bool   // passing through the rest of a long line
+	label   []byte // merge label of the current line, not compared
+	key     []byte
+}
+
+type heldLine struct {
+	key     string
+	raw     []byte
+	repeats int
+}
+
+// newDeduper writes to w and counts collapsed lines in stats
+func (c *Cleaner) newDeduper(w io.Writer, stats *Stats) *deduper {
+	return &deduper{
+		w:      w,
+		window: c.opts.DedupWindow,
+		exact:  c.opts.DedupExact,
+		// Room for a truncated line with its marker and terminator
+		limit: c.opts.MaxLineLength + 64,
+		stats: stats,
+		index: make(map[string]*heldLine),
+	}
+}
+
+func (d *deduper) Write(p []byte) (int, error) {
+	if d.window <= 0 {
+		return d.w.Write(p)
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n') + 1
+		if i == 0 {
+			i = len(p)
+		}
+		line := p[:i]
+		p = p[i:]
+		complete := line[len(line)-1] == '\n'
+
+		if d.long {
+			if _, err := d.w.Write(line); err != nil {
+				return 0, err
+			}
+			d.long = !complete
+			continue
+		}
+
+		if len(d.partial)+len(line) > d.limit {
+			if err := d.passLong(line, complete); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if !complete {
+			d.partial = append(d.partial, line...)
+			continue
+		}
+
+		if len(d.partial) > 0 {
+			line = append(d.partial, line...)
+			d.partial = d.partial[:0]
+		}
+		if err := d.add(line); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Flush writes all held lines, including an unterminated last line
+func (d *deduper) Flush() error {
+	if len(d.partial) > 0 {
+		line := d.partial
+		d.partial = nil
+		if err := d.add(line); err != nil {
+			return err
+		}
+	}
+	return d.flushHeld()
+}
+
+// flushHeld writes the held complete lines, keeping a line still being
+// written for when it is complete
+func (d *deduper) flushHeld() error {
+	for len(d.held) > 0 {
+		if err := d.release(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *deduper) add(raw []byte) error {
+	content := bytes.TrimPrefix(lineContent(raw), d.label)
+	if d.exact {
+		d.key = append(d.key[:0], content...)
+	} else {
+		d.key = normalize.Append(d.key[:0], content)
+	}
+
+	if held, ok := d.index[string(d.key)]; ok {
+		held.repeats++
+		d.stats.DuplicateLines++
+		return nil
+	}
+
+	held := &heldLine{key: string(d.key), raw: bytes.Clone(raw)}
+	d.held = append(d.held, held)
+	d.index[held.key] = held
+	if len(d.held) > d.window {
+		return d.release()
+	}
+	return nil
+}
+
+// release writes the oldest held line
+func (d *deduper) release() error {
+	held := d.held[0]
+	d.held[0] = nil
+	d.held = d.held[1:]
+	delete(d.index, held.key)
+
+	if held.repeats == 0 {
+		_, err := d.w.Write(held.raw)
+		return err
+	}
+	content := lineContent(held.raw)
+	for _, part := range [][]byte{content, repeatMarker(held.repeats), held.raw[len(content):]} {
+		if _, err := d.w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// passLong flushes the held lines and passes through a line too long to
+// hold, starting with its partial beginning; the rest of an incomplete
+// line follows in later writes
+func (d *deduper) passLong(line []byte, complete bool) error {
+	for len(d.held) > 0 {
+		if err := d.release(); err != nil {
+			return err
+		}
+	}
+	if len(d.partial) > 0 {
+		partial := d.partial
+		d.partial = nil
+		if _, err := d.w.Write(partial); err != nil {
+			return err
+		}
+	}
+	d.long = !complete
+	_, err := d.w.Write(line)
+	return err
+}
+
+func repeatMarker(repeats int) []byte {
+	return fmt.Appendf(nil, " [repeated %d times]", repeats)
+}
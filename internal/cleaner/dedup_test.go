@@ -0,0 +1,103 @@
+package cleaner
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+func TestClean_Dedup(t *testing.T) {
+	input := "10:00:01 connection from 10.0.0.1 refused\n" +
+		"10:00:02 connection from 10.0.0.2 refused\n" +
+		"DEBUG: noise\n" +
+		"10:00:03 connection from 10.0.0.3 refused\r\n" +
+		"10:00:04 retrying\n" +
+		"10:00:05 connection from 10.0.0.4 refused\n" +
+		"10:00:06 retrying"
+
+	tests := []struct {
+		name       string
+		opts       Options
+		want       string
+		duplicates int
+	}{
+		{
+			"off",
+			Options{},
+			strings.ReplaceAll(input, "DEBUG: noise\n", ""),
+			0,
+		},
+		{
+			"consecutive",
+			Options{DedupWindow: 1},
+			"10:00:01 connection from 10.0.0.1 refused [repeated 2 times]\n" +
+				"10:00:04 retrying\n" +
+				"10:00:05 connection from 10.0.0.4 refused\n" +
+				"10:00:06 retrying",
+			2,
+		},
+		{
+			"window",
+			Options{DedupWindow: 2},
+			"10:00:01 connection from 10.0.0.1 refused [repeated 3 times]\n" +
+				"10:00:04 retrying [repeated 1 times]\n",
+			4,
+		},
+		{
+			"exact",
+			Options{DedupWindow: 2, DedupExact: true},
+			strings.ReplaceAll(input, "DEBUG: noise\n", ""),
+			0,
+		},
+	}
+
+	f, _ := filter.New("remove-debug", "^DEBUG", filter.TypeRemove)
+	for _, tt := range tests {
+		for _, workers := range []int{1, 4} {
+			t.Run(fmt.Sprintf("%s/workers=%d", tt.name, workers), func(t *testing.T) {
+				tt.opts.Workers = workers
+				tt.opts.ChunkSize = 16
+
+				var out bytes.Buffer
+				stats, err := NewWithOptions([]*filter.Filter{f}, tt.opts).cleanStream(strings.NewReader(input), textenc.Detection{}, &out, nil)
+				if err != nil {
+					t.Fatalf("cleanStream() error = %v", err)
+				}
+				if got := out.String(); got != tt.want {
+					t.Errorf("output = %q, want %q", got, tt.want)
+				}
+				if stats.DuplicateLines != tt.duplicates {
+					t.Errorf("DuplicateLines = %d, want %d", stats.DuplicateLines, tt.duplicates)
+				}
+				if want := len(strings.SplitAfter(strings.TrimSuffix(tt.want, "\n"), "\n")); stats.RemainingLines() != want {
+					t.Errorf("RemainingLines() = %d, want %d", stats.RemainingLines(), want)
+				}
+			})
+		}
+	}
+}
+
+func TestClean_DedupLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	input := "a 1\na 2\n" + long + "\n" + long + "\na 3\n"
+
+	var out bytes.Buffer
+	c := NewWithOptions(nil, Options{MaxLineLength: 16, DedupWindow: 2})
+	stats, err := c.cleanStream(strings.NewReader(input), textenc.Detection{}, &out, nil)
+	if err != nil {
+		t.Fatalf("cleanStream() error = %v", err)
+	}
+
+	// Long lines pass through and end the lines held for comparison
+	want := "a 1 [repeated 1 times]\n" + long + "\n" + long + "\na 3\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if stats.DuplicateLines != 1 {
+		t.Errorf("DuplicateLines = %d, want 1", stats.DuplicateLines)
+	}
+}
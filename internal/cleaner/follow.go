@@ -0,0 +1,217 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+const defaultPollInterval = 250 * time.Millisecond
+
+// FollowOptions configure Follow
+type FollowOptions struct {
+	// FromStart cleans the existing content first instead of starting at
+	// the current end of the file
+	FromStart bool
+	// PollInterval is how often the file is checked for new data, rotation
+	// and truncation; it defaults to 250ms
+	PollInterval time.Duration
+	// OnUpdate is called with a snapshot of the stats whenever new lines
+	// have been processed and the output has been flushed
+	OnUpdate func(Stats)
+}
+
+// Follow cleans inputPath like tail -f, writing kept lines to w as the file
+// grows until ctx is done. A file renamed away and replaced (logrotate's
+// create mode) is read to its end before switching to the new file; a file
+// that shrinks (copytruncate) is read again from the beginning.
+//
+// Frequency filters count shapes as lines arrive, so a shape is removed
+// from its occurrence after the threshold on.
+//
+// Follow always runs sequentially. A line is only processed once its line
+// break has been written, except for a partial last line when ctx is done.
+func (c *Cleaner) Follow(ctx context.Context, inputPath string, w io.Writer, opts FollowOptions) (*Stats, error) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+
+	detection, err := c.detectEncoding(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+
+	var offset int64
+	if !opts.FromStart {
+		if offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to seek input file: %w", err)
+		}
+		// The byte order mark is only at the start of the file
+		detection.BOM = false
+	}
+
+	stats := &Stats{Encoding: detection, FilterHits: make([]int, len(c.filters))}
+
+	if c.beginShapes(true) {
+		defer c.endShapes()
+	}
+	c.beginSampling()
+	defer c.endSampling()
+
+	var output io.Writer = w
+	var encoder io.WriteCloser
+	if c.opts.PreserveEncoding {
+		encoder = textenc.NewEncoder(w, detection)
+		output = encoder
+	}
+	writer := bufio.NewWriter(output)
+	dedup := c.newDeduper(writer, stats)
+
+	var flushErr error
+	reported := 0
+	fr := &followReader{
+		ctx:    ctx,
+		path:   inputPath,
+		file:   file,
+		offset: offset,
+		poll:   opts.PollInterval,
+		idle: func() {
+			if stats.TotalLines == reported || flushErr != nil {
+				return
+			}
+			// Repeats are only collapsed within what arrives between pauses.
+			// A pause may fall inside a line, which stays held until its end.
+			if flushErr = dedup.flushHeld(); flushErr != nil {
+				return
+			}
+			if flushErr = writer.Flush(); flushErr != nil {
+				return
+			}
+			reported = stats.TotalLines
+			if opts.OnUpdate != nil {
+				opts.OnUpdate(stats.Snapshot())
+			}
+		},
+	}
+	defer fr.close()
+
+	err = c.cleanSequential(textenc.NewDecoder(fr, detection), dedup, stats, nil)
+	if err == nil && flushErr != nil {
+		err = fmt.Errorf("failed to write output: %w", flushErr)
+	}
+	if dErr := dedup.Flush(); err == nil && dErr != nil {
+		err = fmt.Errorf("failed to write output: %w", dErr)
+	}
+	if fErr := writer.Flush(); err == nil && fErr != nil {
+		err = fmt.Errorf("failed to write output: %w", fErr)
+	}
+	if encoder != nil {
+		if closeErr := encoder.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write output: %w", closeErr)
+		}
+	}
+
+	if err == nil && opts.OnUpdate != nil && stats.TotalLines != reported {
+		opts.OnUpdate(stats.Snapshot())
+	}
+	return stats, err
+}
+
+// followReader reads a file across rotations and blocks at its end until
+// more data arrives. It reports io.EOF once ctx is done.
+type followReader struct {
+	ctx    context.Context
+	path   string
+	file   *os.File
+	offset int64
+	poll   time.Duration
+	// idle is called each time the reader has caught up with the file
+	idle func()
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		if r.ctx.Err() != nil {
+			return 0, io.EOF
+		}
+
+		n, err := r.file.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, fmt.Errorf("failed to read input file: %w", err)
+		}
+
+		switched, err := r.checkRotation()
+		if err != nil {
+			return 0, err
+		}
+		if switched {
+			continue
+		}
+
+		r.idle()
+
+		select {
+		case <-r.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(r.poll):
+		}
+	}
+}
+
+// checkRotation detects a replaced or truncated file and repositions the
+// reader, reporting whether there may be new data to read
+func (r *followReader) checkRotation() (bool, error) {
+	current, err := r.file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat input file: %w", err)
+	}
+
+	// A missing path is a rotation in progress; keep the old file until the
+	// new one has been created
+	if info, err := os.Stat(r.path); err == nil && !os.SameFile(info, current) {
+		// The writer may have appended to the old file since the last read
+		if remaining := current.Size() - r.offset; remaining > 0 {
+			return true, nil
+		}
+
+		file, err := os.Open(r.path)
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to open rotated input file: %w", err)
+		}
+		r.file.Close()
+		r.file, r.offset = file, 0
+		return true, nil
+	}
+
+	if current.Size() < r.offset {
+		if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+			return false, fmt.Errorf("failed to seek input file: %w", err)
+		}
+		r.offset = 0
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *followReader) close() {
+	r.file.Close()
+}
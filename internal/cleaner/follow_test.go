@@ -0,0 +1,243 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+// syncBuffer is a bytes.Buffer safe for use from the follow goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFollow(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "app.log")
+
+	write := func(path string, flag int, data string) {
+		t.Helper()
+		f, err := os.OpenFile(path, flag|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out syncBuffer
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.HasSuffix(out.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q, output so far %q", want, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	write(inputPath, os.O_CREATE, "INFO: a\nERROR: b\n")
+
+	f, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+	c := New([]*filter.Filter{f})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var updates []Stats
+	type result struct {
+		stats *Stats
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		stats, err := c.Follow(ctx, inputPath, &out, FollowOptions{
+			FromStart:    true,
+			PollInterval: 5 * time.Millisecond,
+			OnUpdate: func(s Stats) {
+				mu.Lock()
+				updates = append(updates, s)
+				mu.Unlock()
+			},
+		})
+		done <- result{stats, err}
+	}()
+
+	waitFor("INFO: a\n")
+
+	write(inputPath, os.O_APPEND, "INFO: c\nERROR: d\nINFO: partial")
+	waitFor("INFO: c\n")
+	write(inputPath, os.O_APPEND, " line\n")
+	waitFor("INFO: partial line\n")
+
+	// Rename and create: lines written to the old file after the rename
+	// come before those of the new file
+	if err := os.Rename(inputPath, inputPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	write(inputPath+".1", os.O_APPEND, "INFO: e\n")
+	write(inputPath, os.O_CREATE|os.O_EXCL, "INFO: f\nINFO: g\n")
+	waitFor("INFO: e\nINFO: f\nINFO: g\n")
+
+	// Copytruncate
+	if err := os.Truncate(inputPath, 0); err != nil {
+		t.Fatal(err)
+	}
+	write(inputPath, os.O_APPEND, "INFO: h\n")
+	waitFor("INFO: g\nINFO: h\n")
+
+	cancel()
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Follow() error = %v", res.err)
+	}
+
+	want := "INFO: a\nINFO: c\nINFO: partial line\nINFO: e\nINFO: f\nINFO: g\nINFO: h\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if res.stats.TotalLines != 9 || res.stats.FilteredLines != 2 || res.stats.FilterHits[0] != 2 {
+		t.Errorf("unexpected stats %+v", *res.stats)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(updates) == 0 || updates[len(updates)-1].TotalLines != 9 {
+		t.Errorf("expected updates ending with the final stats, got %+v", updates)
+	}
+}
+
+func TestFollow_StartsAtEnd(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "app.log")
+	if err := os.WriteFile(inputPath, []byte("INFO: old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updated := make(chan Stats, 16)
+
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		_, err := New(nil).Follow(ctx, inputPath, &out, FollowOptions{
+			PollInterval: 5 * time.Millisecond,
+			OnUpdate:     func(s Stats) { updated <- s },
+		})
+		done <- err
+	}()
+
+	// Give Follow time to open the file before appending
+	time.Sleep(50 * time.Millisecond)
+	f, err := os.OpenFile(inputPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("INFO: new\n")
+	f.Close()
+
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an update")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+
+	if got := out.String(); got != "INFO: new\n" {
+		t.Errorf("output = %q, want only the appended line", got)
+	}
+}
+
+func TestFollow_PauseInsideLongLine(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "app.log")
+	if err := os.WriteFile(inputPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	appendLog := func(data string) {
+		t.Helper()
+		f, err := os.OpenFile(inputPath, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updated := make(chan Stats, 64)
+	waitForLines := func(lines int) {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case s := <-updated:
+				if s.TotalLines >= lines {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("timed out waiting for %d lines", lines)
+			}
+		}
+	}
+
+	var out syncBuffer
+	done := make(chan error, 1)
+	c := NewWithOptions(nil, Options{MaxLineLength: 16, LongLines: LongLineTruncate, DedupWindow: 2})
+	go func() {
+		_, err := c.Follow(ctx, inputPath, &out, FollowOptions{
+			FromStart:    true,
+			PollInterval: 5 * time.Millisecond,
+			OnUpdate:     func(s Stats) { updated <- s },
+		})
+		done <- err
+	}()
+
+	// The long line pauses after its truncated prefix, which equals the
+	// line before it
+	prefix := strings.Repeat("x", 16)
+	appendLog(prefix + "\n" + strings.Repeat("x", 40))
+	waitForLines(2)
+	appendLog("\nINFO: b\n")
+	waitForLines(3)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+
+	want := prefix + "\n" + prefix + " [truncated 24 bytes]\nINFO: b\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
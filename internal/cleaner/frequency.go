@@ -0,0 +1,110 @@
+package cleaner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sstreichan/logcleaner/internal/countmin"
+	"github.com/sstreichan/logcleaner/internal/normalize"
+)
+
+const (
+	// The shape counts take 4 MiB; with conservative update a shape is
+	// rarely overestimated by more than a few lines below 10 million lines
+	shapeSketchWidth = 1 << 18
+	shapeSketchDepth = 4
+
+	// maxSuppressedShapes bounds the shapes listed in Stats.Suppressed
+	maxSuppressedShapes = 1000
+)
+
+// ShapeCount is a line shape, as normalized by package normalize, and the
+// number of lines of that shape removed by a frequency filter
+type ShapeCount struct {
+	Shape string
+	Count int
+}
+
+// TopSuppressed returns up to n of the shapes removed by frequency filters,
+// most lines first
+func (s *Stats) TopSuppressed(n int) []ShapeCount {
+	shapes := make([]ShapeCount, 0, len(s.Suppressed))
+	for shape, count := range s.Suppressed {
+		shapes = append(shapes, ShapeCount{Shape: shape, Count: count})
+	}
+	sort.Slice(shapes, func(i, j int) bool {
+		if shapes[i].Count != shapes[j].Count {
+			return shapes[i].Count > shapes[j].Count
+		}
+		return shapes[i].Shape < shapes[j].Shape
+	})
+	if len(shapes) > n {
+		shapes = shapes[:n]
+	}
+	return shapes
+}
+
+func (s *Stats) addSuppressed(shape string, count int) {
+	if _, ok := s.Suppressed[shape]; !ok && len(s.Suppressed) == maxSuppressedShapes {
+		return
+	}
+	if s.Suppressed == nil {
+		s.Suppressed = make(map[string]int)
+	}
+	s.Suppressed[shape] += count
+}
+
+// beginShapes prepares a run of the frequency filters. Unless online is
+// set, the shapes must then be counted with countShapes before filtering;
+// online counts shapes as lines are filtered, so a shape is only removed
+// once it has occurred more than Threshold times. It reports whether there
+// are frequency filters; endShapes must be called after the run if so.
+func (c *Cleaner) beginShapes(online bool) bool {
+	if !c.frequency {
+		return false
+	}
+	c.shapes = countmin.New(shapeSketchWidth, shapeSketchDepth)
+	c.countOnline = online
+	return true
+}
+
+func (c *Cleaner) endShapes() {
+	c.shapes = nil
+	c.countOnline = false
+}
+
+// countShapes is the first pass of the frequency filters: it counts the
+// shapes of all lines in r as the filters will see them
+func (c *Cleaner) countShapes(r io.Reader) error {
+	reader := c.newLineReader(r)
+	var key []byte
+	skipping := false
+
+	for {
+		raw, err := reader.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+
+		// Filters only see the first MaxLineLength bytes of a line, and no
+		// long lines at all if those are dropped
+		if len(raw) > 0 && !skipping {
+			content := lineContent(raw)
+			long := len(content) > c.opts.MaxLineLength
+			if long {
+				content = content[:c.opts.MaxLineLength]
+			}
+			if !long || c.opts.LongLines != LongLineDrop {
+				key = normalize.Append(key[:0], content)
+				c.shapes.Add(key)
+			}
+		}
+		skipping = err == bufio.ErrBufferFull
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestClean_Frequency(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	// 60 heartbeats, 6 logins and 3 disk errors
+	var sb strings.Builder
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&sb, "INFO heartbeat %d ok\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&sb, "WARN user %d logged in\n", i)
+		}
+		if i%20 == 0 {
+			fmt.Fprintf(&sb, "ERROR disk %d failing\n", i)
+		}
+	}
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	noise, _ := filter.NewFrequency("noise", "", 5, filter.Options{})
+	infoNoise, _ := filter.NewFrequency("info noise", "^INFO", 5, filter.Options{})
+	heartbeat := ShapeCount{Shape: "INFO heartbeat <NUM> ok", Count: 60}
+	logins := ShapeCount{Shape: "WARN user <NUM> logged in", Count: 6}
+
+	tests := []struct {
+		name           string
+		filter         *filter.Filter
+		wantRemaining  int
+		wantSuppressed []ShapeCount
+	}{
+		{"all lines", noise, 3, []ShapeCount{heartbeat, logins}},
+		{"scoped by pattern", infoNoise, 9, []ShapeCount{heartbeat}},
+	}
+
+	for _, tt := range tests {
+		for _, workers := range []int{1, 4} {
+			t.Run(fmt.Sprintf("%s/workers=%d", tt.name, workers), func(t *testing.T) {
+				outputPath := filepath.Join(tempDir, "output.log")
+				c := NewWithOptions([]*filter.Filter{tt.filter}, Options{Workers: workers, ChunkSize: 64})
+				stats, err := c.Clean(inputPath, outputPath, nil)
+				if err != nil {
+					t.Fatalf("Clean() error = %v", err)
+				}
+
+				output, _ := os.ReadFile(outputPath)
+				if strings.Contains(string(output), "heartbeat") || !strings.Contains(string(output), "ERROR disk 40 failing") {
+					t.Errorf("output should keep the rare lines only:\n%s", output)
+				}
+				if stats.RemainingLines() != tt.wantRemaining {
+					t.Errorf("RemainingLines() = %d, want %d", stats.RemainingLines(), tt.wantRemaining)
+				}
+				if got := stats.TopSuppressed(10); !reflect.DeepEqual(got, tt.wantSuppressed) {
+					t.Errorf("TopSuppressed() = %v, want %v", got, tt.wantSuppressed)
+				}
+				if stats.FilterHits[0] != stats.FilteredLines {
+					t.Errorf("FilterHits = %v, FilteredLines = %d", stats.FilterHits, stats.FilteredLines)
+				}
+			})
+		}
+	}
+}
+
+func TestCleanMerged_FrequencyCountsAllInputs(t *testing.T) {
+	tempDir := t.TempDir()
+	var paths []string
+	for i, content := range []string{
+		"2024-01-01T00:00:01Z job 1 done\n2024-01-01T00:00:03Z job 3 done\n",
+		"2024-01-01T00:00:02Z job 2 done\n2024-01-01T00:00:04Z backup finished\n",
+	} {
+		path := filepath.Join(tempDir, fmt.Sprintf("host%d.log", i))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	noise, _ := filter.NewFrequency("noise", "", 2, filter.Options{})
+	var out strings.Builder
+	if _, err := New([]*filter.Filter{noise}).CleanMerged(paths, &out, MergeOptions{}, nil); err != nil {
+		t.Fatalf("CleanMerged() error = %v", err)
+	}
+
+	// No single input has the job shape more than twice
+	if got := out.String(); got != "2024-01-01T00:00:04Z backup finished\n" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestClean_FrequencyIgnoresDroppedLongLines(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	// Two short lines and three long ones of the same shape once truncated
+	short := "INFO: request 01"
+	input := short + "\n" + short + "\n" + strings.Repeat(short+" and much more\n", 3)
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := filter.NewFrequency("noise", "", 2, filter.Options{})
+	c := NewWithOptions([]*filter.Filter{f}, Options{MaxLineLength: len(short), LongLines: LongLineDrop})
+	outputPath := filepath.Join(tempDir, "output.log")
+	stats, err := c.Clean(inputPath, outputPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, _ := os.ReadFile(outputPath)
+	if string(output) != short+"\n"+short+"\n" || stats.DroppedLongLines != 3 || stats.FilteredLines != 0 {
+		t.Errorf("output = %q, stats = %+v", output, *stats)
+	}
+}
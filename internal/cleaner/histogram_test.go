@@ -0,0 +1,70 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/histogram"
+	"github.com/sstreichan/logcleaner/internal/severity"
+)
+
+func TestClean_Histogram(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	var sb strings.Builder
+	sb.WriteString("boot banner without timestamp\n")
+	for i := 0; i < 300; i++ {
+		level := "INFO"
+		if i%10 == 0 {
+			level = "ERROR"
+		}
+		fmt.Fprintf(&sb, "2024-01-31T12:%02d:%02dZ %s request %d\n", i/60, i%60, level, i)
+		if level == "ERROR" {
+			sb.WriteString("  at handler.go:42\n")
+		}
+	}
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := filter.New("remove-info", "INFO", filter.TypeRemove)
+	filters := []*filter.Filter{info}
+
+	seq, err := NewWithOptions(filters, Options{Histogram: true}).CleanTo(inputPath, &strings.Builder{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := seq.Histogram
+
+	if got := h.Levels[severity.Error]; got != (histogram.Count{Total: 30, Kept: 30}) {
+		t.Errorf("Levels[Error] = %+v", got)
+	}
+	if got := h.Levels[severity.Info]; got != (histogram.Count{Total: 270, Kept: 0}) {
+		t.Errorf("Levels[Info] = %+v", got)
+	}
+	if got := h.Untimed(); got != (histogram.Count{Total: 1, Kept: 1}) {
+		t.Errorf("Untimed() = %+v", got)
+	}
+
+	// Trace lines count towards the minute of their error
+	series := h.Series(5)
+	if len(series.Counts) != 5 || series.Counts[0] != (histogram.Count{Total: 66, Kept: 12}) {
+		t.Errorf("Series(5) = %+v", series)
+	}
+
+	for _, chunkSize := range []int{64, 1000} {
+		par, err := NewWithOptions(filters, Options{Histogram: true, Workers: 4, ChunkSize: chunkSize}).CleanTo(inputPath, &strings.Builder{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(par.Histogram.Series(100), h.Series(100)) || par.Histogram.Levels != h.Levels || par.Histogram.Untimed() != h.Untimed() {
+			t.Errorf("chunk=%d: parallel histogram differs from sequential", chunkSize)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/discover"
+)
+
+// InPlaceOptions configure CleanInPlace
+type InPlaceOptions struct {
+	// Backup keeps the original content with discover.BackupSuffix
+	Backup bool
+}
+
+// CleanInPlace replaces the file at path with its cleaned content. The
+// output goes to a temporary file in the same directory, which gets the
+// original's permissions, ownership and modification time and is then
+// renamed over it. If the file changes while it is being cleaned, as it
+// does while a process is still writing to it, nothing is replaced. A
+// writer that is idle during the clean but keeps the file open is not
+// noticed; its later writes go to the replaced file and are lost.
+func (c *Cleaner) CleanInPlace(path string, opts InPlaceOptions, progressCb func(int, int)) (*Stats, error) {
+	// Replace the file a symlink points to, not the link
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	inFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer inFile.Close()
+
+	before, err := inFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat input file: %w", err)
+	}
+	if !before.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	stats, err := c.cleanFile(inFile, tmp, progressCb)
+	if err != nil {
+		return stats, err
+	}
+
+	if err := tmp.Chmod(before.Mode().Perm()); err != nil {
+		return stats, fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := chown(tmp, before); err != nil {
+		return stats, fmt.Errorf("failed to set ownership: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return stats, fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return stats, fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := os.Chtimes(tmp.Name(), time.Now(), before.ModTime()); err != nil {
+		return stats, fmt.Errorf("failed to set modification time: %w", err)
+	}
+
+	if err := checkUnchanged(path, before); err != nil {
+		return stats, err
+	}
+
+	if opts.Backup {
+		if err := backup(path, before); err != nil {
+			return stats, err
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return stats, fmt.Errorf("failed to replace original file: %w", err)
+	}
+	committed = true
+
+	return stats, nil
+}
+
+// checkUnchanged refuses to replace a file that was written to or replaced
+// since before was taken
+func checkUnchanged(path string, before os.FileInfo) error {
+	after, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat input file: %w", err)
+	}
+	if !os.SameFile(before, after) || after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) {
+		return fmt.Errorf("%s changed while it was being cleaned; is it still being written to?", path)
+	}
+	return nil
+}
+
+// backup preserves the original at path+discover.BackupSuffix, as a hard link where
+// possible so no data has to be copied
+func backup(path string, info os.FileInfo) error {
+	bak := path + discover.BackupSuffix
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old backup: %w", err)
+	}
+	if err := os.Link(path, bak); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(bak, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	chown(dst, info)
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return os.Chtimes(bak, time.Now(), info.ModTime())
+}
@@ -0,0 +1,95 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/discover"
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestCleanInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	original := "INFO: a\nERROR: b\nINFO: c\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+	stats, err := New([]*filter.Filter{f}).CleanInPlace(path, InPlaceOptions{Backup: true}, nil)
+	if err != nil {
+		t.Fatalf("CleanInPlace() error = %v", err)
+	}
+	if stats.FilteredLines != 1 {
+		t.Errorf("FilteredLines = %d, want 1", stats.FilteredLines)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "INFO: a\nINFO: c\n" {
+		t.Errorf("content = %q", got)
+	}
+	bak, _ := os.ReadFile(path + discover.BackupSuffix)
+	if string(bak) != original {
+		t.Errorf("backup = %q, want the original", bak)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), mtime)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected only the file and its backup, got %d entries", len(entries))
+	}
+}
+
+func TestCleanInPlace_RefusesChangingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	original := strings.Repeat("INFO: line\n", 2500)
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process appends while the file is being cleaned
+	appended := false
+	_, err := New(nil).CleanInPlace(path, InPlaceOptions{}, func(lines, filtered int) {
+		if appended {
+			return
+		}
+		appended = true
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString("INFO: late\n")
+		f.Close()
+	})
+	if err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Fatalf("CleanInPlace() error = %v, want a changed file error", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != original+"INFO: late\n" {
+		t.Error("the file was replaced although it changed")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,136 @@
+package cleaner
+
+import (
+	"sort"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+// matcher evaluates a filter set as a whole. Filters with required literals
+// are only confirmed by their regex when an Aho-Corasick scan has found one
+// of those literals in the line; all other filters are always evaluated.
+type matcher struct {
+	filters []*filter.Filter
+	ac      *ahoCorasick
+
+	// patternFilters maps an automaton pattern to the filters requiring it
+	patternFilters [][]int
+	// unindexed filters have no literals and are always evaluated
+	unindexed []int
+	// absentDrops are indexed filters that reject a line lacking their literals
+	absentDrops []int
+}
+
+// matchScratch is per-goroutine state reused across lines
+type matchScratch struct {
+	marks      []uint32
+	gen        uint32
+	candidates []int
+	key        []byte // normalized line for frequency filters
+}
+
+func newMatcher(filters []*filter.Filter) *matcher {
+	m := &matcher{filters: filters}
+
+	var patterns []string
+	patternIDs := make(map[string]int)
+	for i, f := range filters {
+		// Frequency and sample filters depend on more than the line and
+		// are applied after the matcher by Cleaner.keep
+		if f.Type == filter.TypeFrequency || f.Type == filter.TypeSample {
+			continue
+		}
+
+		lits := f.Literals()
+		if lits == nil {
+			m.unindexed = append(m.unindexed, i)
+			continue
+		}
+
+		for _, lit := range lits {
+			id, ok := patternIDs[lit]
+			if !ok {
+				id = len(patterns)
+				patternIDs[lit] = id
+				patterns = append(patterns, lit)
+				m.patternFilters = append(m.patternFilters, nil)
+			}
+			m.patternFilters[id] = append(m.patternFilters[id], i)
+		}
+
+		// Without its literals a filter's pattern cannot match, so Matches
+		// returns Invert; that rejects the line for these two combinations
+		if (f.Type == filter.TypeRemove && f.Invert) || (f.Type == filter.TypeKeep && !f.Invert) {
+			m.absentDrops = append(m.absentDrops, i)
+		}
+	}
+
+	if len(patterns) > 0 {
+		m.ac = newAhoCorasick(patterns)
+	}
+
+	return m
+}
+
+func (m *matcher) newScratch() *matchScratch {
+	return &matchScratch{marks: make([]uint32, len(m.filters))}
+}
+
+// keep reports whether line survives the filter set and, if not, the index
+// of the first filter in order that rejects it
+func (m *matcher) keep(line []byte, s *matchScratch) (bool, int) {
+	if len(m.filters) == 0 {
+		return true, -1
+	}
+
+	s.gen++
+	if s.gen == 0 {
+		for i := range s.marks {
+			s.marks[i] = 0
+		}
+		s.gen = 1
+	}
+	s.candidates = append(s.candidates[:0], m.unindexed...)
+
+	if m.ac != nil {
+		ac := m.ac
+		state := int32(0)
+		for i := 0; i < len(line); i++ {
+			state = ac.delta[int(state)*ac.nclass+int(ac.classes[line[i]])]
+			for _, id := range ac.out[state] {
+				for _, fi := range m.patternFilters[id] {
+					if s.marks[fi] != s.gen {
+						s.marks[fi] = s.gen
+						s.candidates = append(s.candidates, fi)
+					}
+				}
+			}
+		}
+	}
+
+	rejectedBy := -1
+	for _, fi := range m.absentDrops {
+		if s.marks[fi] != s.gen {
+			rejectedBy = fi
+			break
+		}
+	}
+
+	sort.Ints(s.candidates)
+	for _, fi := range s.candidates {
+		if rejectedBy >= 0 && fi >= rejectedBy {
+			break
+		}
+		if rejects(m.filters[fi], line) {
+			rejectedBy = fi
+			break
+		}
+	}
+
+	return rejectedBy < 0, rejectedBy
+}
+
+func rejects(f *filter.Filter, line []byte) bool {
+	matches := f.MatchesBytes(line)
+	return (f.Type == filter.TypeRemove && matches) || (f.Type == filter.TypeKeep && !matches)
+}
@@ -0,0 +1,119 @@
+package cleaner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func naiveKeep(filters []*filter.Filter, line []byte) (bool, int) {
+	for i, f := range filters {
+		if rejects(f, line) {
+			return false, i
+		}
+	}
+	return true, -1
+}
+
+func TestMatcherAgreesWithNaive(t *testing.T) {
+	specs := []struct {
+		pattern string
+		typ     filter.FilterType
+		opts    filter.Options
+	}{
+		{"^DEBUG", filter.TypeRemove, filter.Options{}},
+		{"timeout|refused", filter.TypeRemove, filter.Options{}},
+		{`\d{3}ms`, filter.TypeRemove, filter.Options{}},
+		{"healthz", filter.TypeRemove, filter.Options{Literal: true}},
+		{"WARN", filter.TypeRemove, filter.Options{IgnoreCase: true}},
+		{"request", filter.TypeKeep, filter.Options{}},
+		{"secret", filter.TypeKeep, filter.Options{Invert: true}},
+		{"user=", filter.TypeRemove, filter.Options{Invert: true}},
+	}
+
+	var filters []*filter.Filter
+	for i, spec := range specs {
+		f, err := filter.NewWithOptions(fmt.Sprintf("f%d", i), spec.pattern, spec.typ, spec.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filters = append(filters, f)
+	}
+
+	lines := []string{
+		"DEBUG request user=1",
+		"INFO request user=2 took 120ms",
+		"INFO request user=3 connection refused",
+		"INFO request user=4 GET /healthz",
+		"warn request user=5",
+		"INFO request user=6 secret token",
+		"INFO request without user",
+		"INFO response user=7",
+		"INFO request user=8 ok",
+		"",
+	}
+
+	// Check every prefix of the filter list to exercise rejection ordering
+	for n := 0; n <= len(filters); n++ {
+		m := newMatcher(filters[:n])
+		scratch := m.newScratch()
+		for _, line := range lines {
+			gotKeep, gotBy := m.keep([]byte(line), scratch)
+			wantKeep, wantBy := naiveKeep(filters[:n], []byte(line))
+			if gotKeep != wantKeep || gotBy != wantBy {
+				t.Errorf("%d filters, line %q: got (%v, %d), want (%v, %d)", n, line, gotKeep, gotBy, wantKeep, wantBy)
+			}
+		}
+	}
+}
+
+func TestAhoCorasickOverlappingPatterns(t *testing.T) {
+	patterns := []string{"he", "she", "his", "hers"}
+	ac := newAhoCorasick(patterns)
+
+	found := make(map[string]bool)
+	state := int32(0)
+	text := "ushers"
+	for i := 0; i < len(text); i++ {
+		state = ac.delta[int(state)*ac.nclass+int(ac.classes[text[i]])]
+		for _, id := range ac.out[state] {
+			found[patterns[id]] = true
+		}
+	}
+
+	for _, want := range []string{"he", "she", "hers"} {
+		if !found[want] {
+			t.Errorf("Expected %q to be found in %q", want, text)
+		}
+	}
+	if found["his"] {
+		t.Errorf("Did not expect %q to be found in %q", "his", text)
+	}
+}
+
+func TestAhoCorasickAllBytes(t *testing.T) {
+	// Every byte value gets its own class, one more than fits in a byte
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(255 - i)
+	}
+	patterns := []string{string(all), "\xff\x00"}
+	ac := newAhoCorasick(patterns)
+	if ac.nclass != 257 {
+		t.Fatalf("nclass = %d, want 257", ac.nclass)
+	}
+
+	found := make(map[int32]bool)
+	state := int32(0)
+	text := "a\xff\x00b"
+	for i := 0; i < len(text); i++ {
+		state = ac.delta[int(state)*ac.nclass+int(ac.classes[text[i]])]
+		for _, id := range ac.out[state] {
+			found[id] = true
+		}
+	}
+	if found[0] || !found[1] {
+		t.Errorf("found = %v, want only pattern 1", found)
+	}
+}
@@ -0,0 +1,373 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/logtime"
+	"github.com/sstreichan/logcleaner/internal/rotation"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+const defaultReorderWindow = 1000
+
+// MergeOptions configure CleanMerged
+type MergeOptions struct {
+	// Labels, one per input, prefix each kept line as "[label] "
+	Labels []string
+	// ReorderWindow is how many records per input are buffered to put
+	// slightly out-of-order lines back in time order; it defaults to 1000
+	ReorderWindow int
+}
+
+// CleanMerged interleaves the lines of several logs by timestamp and
+// cleans the merged stream into w. Lines without a timestamp, such as
+// stack traces, stay attached to the line before them. Inputs are expected
+// to be mostly in time order; within ReorderWindow records an input may be
+// out of order. Gzipped inputs are decompressed.
+//
+// Merging always runs sequentially. Lines longer than MaxLineLength are
+// truncated even with LongLinePassThrough, or dropped with LongLineDrop.
+func (c *Cleaner) CleanMerged(paths []string, w io.Writer, opts MergeOptions, progressCb func(int, int)) (*Stats, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no input files")
+	}
+	if opts.Labels != nil && len(opts.Labels) != len(paths) {
+		return nil, fmt.Errorf("got %d labels for %d inputs", len(opts.Labels), len(paths))
+	}
+	if opts.ReorderWindow <= 0 {
+		opts.ReorderWindow = defaultReorderWindow
+	}
+
+	sources := make([]*mergeSource, 0, len(paths))
+	defer func() {
+		for _, s := range sources {
+			s.closer.Close()
+		}
+	}()
+
+	for i, path := range paths {
+		s, err := c.openMergeSource(path)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Labels != nil {
+			s.label = []byte("[" + opts.Labels[i] + "] ")
+		}
+		sources = append(sources, s)
+		if err := s.fill(c, opts.ReorderWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	// The output has a single encoding
+	if c.opts.PreserveEncoding {
+		for i, s := range sources[1:] {
+			if s.detection.Encoding != sources[0].detection.Encoding {
+				return nil, fmt.Errorf("cannot preserve the encoding: %s is %s but %s is %s", paths[0], sources[0].detection.Encoding, paths[i+1], s.detection.Encoding)
+			}
+		}
+	}
+
+	if c.beginShapes(false) {
+		defer c.endShapes()
+		for i, path := range paths {
+			if err := c.countMergeShapes(path, sources[i].detection); err != nil {
+				return nil, err
+			}
+		}
+	}
+	c.beginSampling()
+	defer c.endSampling()
+
+	detection := sources[0].detection
+	stats := c.newStats(detection)
+
+	var output io.Writer = w
+	var encoder io.WriteCloser
+	if c.opts.PreserveEncoding {
+		encoder = textenc.NewEncoder(w, detection)
+		output = encoder
+	}
+	writer := bufio.NewWriter(output)
+	dedup := c.newDeduper(writer, stats)
+	labeled := &labelWriter{w: dedup}
+	// Templates are mined without labels so they match the input lines
+	miner := c.newTemplateMiner(labeled)
+
+	err := c.mergeSources(sources, labeled, miner, opts.ReorderWindow, stats, progressCb)
+	stats.Templates = miner.top(c.opts.Templates)
+
+	if dedupErr := dedup.Flush(); err == nil && dedupErr != nil {
+		err = fmt.Errorf("failed to write output: %w", dedupErr)
+	}
+	if flushErr := writer.Flush(); err == nil && flushErr != nil {
+		err = fmt.Errorf("failed to write output: %w", flushErr)
+	}
+	if encoder != nil {
+		if closeErr := encoder.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write output: %w", closeErr)
+		}
+	}
+
+	return stats, err
+}
+
+// mergeSources writes kept lines to w, which passes them on to labels
+func (c *Cleaner) mergeSources(sources []*mergeSource, labels *labelWriter, w io.Writer, window int, stats *Stats, progressCb func(int, int)) error {
+	for {
+		var next *mergeSource
+		for _, s := range sources {
+			if len(s.buffered) > 0 && (next == nil || s.buffered[0].time.Before(next.buffered[0].time)) {
+				next = s
+			}
+		}
+		if next == nil {
+			return nil
+		}
+
+		rec := heap.Pop(&next.buffered).(*record)
+		for _, line := range rec.lines {
+			labels.start(next.label)
+
+			if err := c.filterMergedLine(line, stats, w); err != nil {
+				return err
+			}
+			if progressCb != nil && stats.TotalLines%1000 == 0 {
+				progressCb(stats.TotalLines, stats.FilteredLines)
+			}
+		}
+
+		if err := next.fill(c, window); err != nil {
+			return err
+		}
+	}
+}
+
+// filterMergedLine is filterLine for lines that may have been cut to fit
+// the read buffer
+func (c *Cleaner) filterMergedLine(line mergedLine, stats *Stats, w io.Writer) error {
+	if line.cut == 0 {
+		return c.filterLine(line.raw, c.scratch, stats, w)
+	}
+
+	stats.TotalLines++
+	stats.LongLines++
+	stats.BytesRead += int64(len(line.raw) + line.cut)
+	content := lineContent(line.raw)
+	if c.opts.LongLines == LongLineDrop {
+		stats.DroppedLongLines++
+		stats.record(content, false)
+		return nil
+	}
+
+	keep, by := c.keep(content, c.scratch)
+	stats.record(content, keep)
+	if !keep {
+		c.reject(by, content, c.scratch, stats)
+		return nil
+	}
+
+	stats.TruncatedLines++
+	return writeAll(w, content, truncationMarker(line.cut), line.raw[len(content):])
+}
+
+// mergedLine is a raw line; cut is the number of content bytes removed
+// from a line too long to buffer
+type mergedLine struct {
+	raw []byte
+	cut int
+}
+
+// record is a line with a timestamp and the lines without one after it
+type record struct {
+	time  time.Time
+	seq   int
+	lines []mergedLine
+}
+
+// recordHeap orders records by time, keeping input order for equal times
+type recordHeap []*record
+
+func (h recordHeap) Len() int { return len(h) }
+func (h recordHeap) Less(i, j int) bool {
+	if h[i].time.Equal(h[j].time) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].time.Before(h[j].time)
+}
+func (h recordHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *recordHeap) Push(x any)   { *h = append(*h, x.(*record)) }
+func (h *recordHeap) Pop() any {
+	old := *h
+	rec := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return rec
+}
+
+type mergeSource struct {
+	label     []byte
+	detection textenc.Detection
+	reader    *bufio.Reader
+	closer    io.Closer
+	parser    logtime.Parser
+
+	current  *record
+	buffered recordHeap
+	seq      int
+	eof      bool
+}
+
+func (c *Cleaner) openMergeSource(path string) (*mergeSource, error) {
+	rc, err := rotation.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	detection, err := textenc.DetectReader(rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect encoding of %s: %w", path, err)
+	}
+	detection = c.overrideEncoding(detection)
+
+	rc, err = rotation.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	return &mergeSource{
+		detection: detection,
+		reader:    c.newLineReader(textenc.NewDecoder(rc, detection)),
+		closer:    rc,
+	}, nil
+}
+
+// countMergeShapes runs the counting pass of the frequency filters on one
+// input
+func (c *Cleaner) countMergeShapes(path string, detection textenc.Detection) error {
+	rc, err := rotation.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer rc.Close()
+
+	return c.countShapes(textenc.NewDecoder(rc, detection))
+}
+
+// fill reads records until window of them are buffered or the input ends
+func (s *mergeSource) fill(c *Cleaner, window int) error {
+	for !s.eof && len(s.buffered) < window {
+		rec, err := s.readRecord(c)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			s.eof = true
+			break
+		}
+		heap.Push(&s.buffered, rec)
+	}
+	return nil
+}
+
+// readRecord returns the next complete record, or nil at the end of input
+func (s *mergeSource) readRecord(c *Cleaner) (*record, error) {
+	for {
+		line, ok, err := s.readLine(c)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			rec := s.current
+			s.current = nil
+			return rec, nil
+		}
+
+		t, timed := s.parser.Parse(lineContent(line.raw))
+		if !timed && s.current != nil {
+			s.current.lines = append(s.current.lines, line)
+			continue
+		}
+
+		// Lines before the first timestamp sort first
+		rec := s.current
+		s.current = &record{time: t, seq: s.seq, lines: []mergedLine{line}}
+		s.seq++
+		if rec != nil {
+			return rec, nil
+		}
+	}
+}
+
+func (s *mergeSource) readLine(c *Cleaner) (mergedLine, bool, error) {
+	raw, err := s.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return s.readLongLine(c, raw)
+	}
+	if err != nil && err != io.EOF {
+		return mergedLine{}, false, fmt.Errorf("error reading file: %w", err)
+	}
+	if len(raw) == 0 {
+		return mergedLine{}, false, nil
+	}
+
+	line := mergedLine{raw: bytes.Clone(raw)}
+	if err == io.EOF {
+		// The last line of an input may end up in the middle of the output
+		line.raw = append(line.raw, '\n')
+	}
+	return line, true, nil
+}
+
+// readLongLine keeps the first MaxLineLength bytes of a line that does not
+// fit into the buffer and its terminator, skipping the rest
+func (s *mergeSource) readLongLine(c *Cleaner, first []byte) (mergedLine, bool, error) {
+	prefix := bytes.Clone(first[:c.opts.MaxLineLength])
+	total := len(first)
+	tail := appendTail(nil, first)
+	for {
+		piece, err := s.reader.ReadSlice('\n')
+		total += len(piece)
+		tail = appendTail(tail, piece)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return mergedLine{}, false, fmt.Errorf("error reading file: %w", err)
+		}
+		break
+	}
+
+	terminator := tail[len(lineContent(tail)):]
+	return mergedLine{
+		raw: append(prefix, terminator...),
+		cut: total - len(terminator) - len(prefix),
+	}, true, nil
+}
+
+// labelWriter prefixes the first write of a line with label
+type labelWriter struct {
+	w       *deduper
+	label   []byte
+	atStart bool
+}
+
+// start begins a line from the input with label. The deduper compares the
+// line without it, so repeats from different inputs still collapse.
+func (l *labelWriter) start(label []byte) {
+	l.label, l.atStart = label, true
+	l.w.label = label
+}
+
+func (l *labelWriter) Write(p []byte) (int, error) {
+	if l.atStart && len(p) > 0 && len(l.label) > 0 {
+		l.atStart = false
+		if _, err := l.w.Write(l.label); err != nil {
+			return 0, err
+		}
+	}
+	return l.w.Write(p)
+}
@@ -0,0 +1,162 @@
+package cleaner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+func TestCleanMerged(t *testing.T) {
+	dir := t.TempDir()
+	inputs := map[string]string{
+		"node1.log": "2024-01-31T10:00:00Z INFO a1\n" +
+			"2024-01-31T10:00:02Z ERROR a2\n" +
+			"java.lang.RuntimeException\n" +
+			"\tat Main.run\n" +
+			"2024-01-31T10:00:04Z INFO a3",
+		// Slightly out of order and in a different zone
+		"node2.log": "2024-01-31T11:00:03+01:00 INFO b2\n" +
+			"2024-01-31T11:00:01+01:00 INFO b1\n" +
+			"2024-01-31T11:00:05+01:00 INFO b3\n",
+	}
+	var paths []string
+	for _, name := range []string{"node1.log", "node2.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(inputs[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	t.Run("labels", func(t *testing.T) {
+		var out bytes.Buffer
+		stats, err := New(nil).CleanMerged(paths, &out, MergeOptions{Labels: []string{"n1", "n2"}}, nil)
+		if err != nil {
+			t.Fatalf("CleanMerged() error = %v", err)
+		}
+
+		want := strings.Join([]string{
+			"[n1] 2024-01-31T10:00:00Z INFO a1",
+			"[n2] 2024-01-31T11:00:01+01:00 INFO b1",
+			"[n1] 2024-01-31T10:00:02Z ERROR a2",
+			"[n1] java.lang.RuntimeException",
+			"[n1] \tat Main.run",
+			"[n2] 2024-01-31T11:00:03+01:00 INFO b2",
+			"[n1] 2024-01-31T10:00:04Z INFO a3",
+			"[n2] 2024-01-31T11:00:05+01:00 INFO b3",
+		}, "\n") + "\n"
+		if out.String() != want {
+			t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+		}
+		if stats.TotalLines != 8 {
+			t.Errorf("TotalLines = %d, want 8", stats.TotalLines)
+		}
+	})
+
+	t.Run("filters see unlabeled lines", func(t *testing.T) {
+		f1, _ := filter.New("remove-errors", `^\S+ ERROR`, filter.TypeRemove)
+		f2, _ := filter.New("remove-traces", `^(java|\tat )`, filter.TypeRemove)
+
+		var out bytes.Buffer
+		stats, err := New([]*filter.Filter{f1, f2}).CleanMerged(paths, &out, MergeOptions{Labels: []string{"n1", "n2"}}, nil)
+		if err != nil {
+			t.Fatalf("CleanMerged() error = %v", err)
+		}
+		if strings.Contains(out.String(), "ERROR") || strings.Contains(out.String(), "Main.run") {
+			t.Errorf("filtered lines in output:\n%s", out.String())
+		}
+		if stats.FilteredLines != 3 || stats.FilterHits[0] != 1 || stats.FilterHits[1] != 2 {
+			t.Errorf("unexpected stats %+v", *stats)
+		}
+	})
+
+	t.Run("window too small", func(t *testing.T) {
+		var out bytes.Buffer
+		if _, err := New(nil).CleanMerged(paths, &out, MergeOptions{ReorderWindow: 1}, nil); err != nil {
+			t.Fatalf("CleanMerged() error = %v", err)
+		}
+		// b2 is emitted before b1 can be seen, but nothing is lost
+		if strings.Count(out.String(), "\n") != 8 {
+			t.Errorf("output lost lines:\n%s", out.String())
+		}
+	})
+
+	t.Run("preserve mixed encodings", func(t *testing.T) {
+		var utf16 bytes.Buffer
+		enc := textenc.NewEncoder(&utf16, textenc.Detection{Encoding: textenc.UTF16LE, BOM: true})
+		enc.Write([]byte("2024-01-31T10:00:01Z INFO c1\n"))
+		enc.Close()
+		path := filepath.Join(dir, "node3.log")
+		if err := os.WriteFile(path, utf16.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		c := NewWithOptions(nil, Options{PreserveEncoding: true})
+		if _, err := c.CleanMerged(append(paths, path), &out, MergeOptions{}, nil); err == nil || out.Len() != 0 {
+			t.Errorf("CleanMerged() error = %v, output %q", err, out.String())
+		}
+		if _, err := New(nil).CleanMerged(append(paths, path), &out, MergeOptions{}, nil); err != nil || !strings.Contains(out.String(), "c1") {
+			t.Errorf("CleanMerged() to UTF-8 error = %v, output %q", err, out.String())
+		}
+	})
+
+	t.Run("label count", func(t *testing.T) {
+		if _, err := New(nil).CleanMerged(paths, &bytes.Buffer{}, MergeOptions{Labels: []string{"n1"}}, nil); err == nil {
+			t.Error("expected an error for a missing label")
+		}
+	})
+}
+
+func TestCleanMerged_DedupLabeled(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"x.log", "y.log"} {
+		path := filepath.Join(dir, name)
+		line := fmt.Sprintf("2024-01-31T10:00:0%dZ INFO ready\n", i)
+		if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	// The lines differ only in their timestamp and label
+	var out bytes.Buffer
+	c := NewWithOptions(nil, Options{DedupWindow: 1})
+	stats, err := c.CleanMerged(paths, &out, MergeOptions{Labels: []string{"x", "y"}}, nil)
+	if err != nil {
+		t.Fatalf("CleanMerged() error = %v", err)
+	}
+	if want := "[x] 2024-01-31T10:00:00Z INFO ready [repeated 1 times]\n"; out.String() != want || stats.DuplicateLines != 1 {
+		t.Errorf("output = %q, DuplicateLines = %d, want %q", out.String(), stats.DuplicateLines, want)
+	}
+}
+
+func TestCleanMerged_LongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	long := "2024-01-31T10:00:00Z " + strings.Repeat("x", 100)
+	if err := os.WriteFile(path, []byte(long+"\r\nshort\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c := NewWithOptions(nil, Options{MaxLineLength: 30})
+	stats, err := c.CleanMerged([]string{path}, &out, MergeOptions{}, nil)
+	if err != nil {
+		t.Fatalf("CleanMerged() error = %v", err)
+	}
+
+	want := long[:30] + " [truncated 91 bytes]\r\nshort\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if stats.LongLines != 1 || stats.TruncatedLines != 1 || stats.BytesRead != int64(len(long)+2+6) {
+		t.Errorf("unexpected stats %+v", *stats)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cleaner
+
+import (
+	"os"
+	"syscall"
+)
+
+// chown gives f the owner and group recorded in info
+func chown(f *os.File, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return f.Chown(int(st.Uid), int(st.Gid))
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package cleaner
+
+import "os"
+
+// chown is a no-op; a new file inherits its ACL from the directory
+func chown(f *os.File, info os.FileInfo) error {
+	return nil
+}
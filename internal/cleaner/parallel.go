@@ -0,0 +1,213 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sstreichan/logcleaner/internal/histogram"
+)
+
+var errAborted = errors.New("processing aborted")
+
+// chunkJob is a newline-aligned slice of the input. Jobs are queued for the
+// writer in input order and handed to the worker pool at the same time, so
+// at most 2*Workers chunks are buffered while waiting to be written.
+//
+// A line too long to buffer is sent as a job without data; its pieces are
+// streamed through the pieces channel once the writer has reached it.
+type chunkJob struct {
+	data   []byte
+	pieces chan []byte
+	result chan chunkResult
+}
+
+type chunkResult struct {
+	out   []byte
+	stats Stats
+	err   error
+}
+
+func (c *Cleaner) cleanParallel(r io.Reader, writer io.Writer, stats *Stats, progressCb func(int, int)) error {
+	workers := c.opts.Workers
+	jobs := make(chan *chunkJob)
+	pending := make(chan *chunkJob, workers*2)
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			scratch := c.matcher.newScratch()
+			for job := range jobs {
+				job.result <- c.processChunk(job.data, scratch)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		readErr <- c.readChunks(r, done, func(job *chunkJob) bool {
+			select {
+			case pending <- job:
+			case <-done:
+				return false
+			}
+			if job.pieces != nil {
+				return true
+			}
+			select {
+			case jobs <- job:
+			case <-done:
+				return false
+			}
+			return true
+		})
+	}()
+
+	abort := func(err error) error {
+		close(done)
+		return err
+	}
+
+	for job := range pending {
+		if job.pieces != nil {
+			for piece := range job.pieces {
+				if _, err := writer.Write(piece); err != nil {
+					return abort(fmt.Errorf("failed to write line: %w", err))
+				}
+			}
+		}
+
+		res := <-job.result
+		if res.err != nil {
+			return abort(res.err)
+		}
+		stats.add(&res.stats)
+
+		if _, err := writer.Write(res.out); err != nil {
+			return abort(fmt.Errorf("failed to write line: %w", err))
+		}
+
+		if progressCb != nil {
+			progressCb(stats.TotalLines, stats.FilteredLines)
+		}
+	}
+
+	return <-readErr
+}
+
+// readChunks splits r into chunks ending at a newline (except possibly the
+// last) and passes each to emit until emit returns false
+func (c *Cleaner) readChunks(r io.Reader, done <-chan struct{}, emit func(*chunkJob) bool) error {
+	reader := c.newLineReader(r)
+	size := c.opts.ChunkSize
+	buf := make([]byte, 0, size)
+
+	for {
+		n, err := io.ReadFull(reader, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+
+		if eof {
+			if len(buf) > 0 {
+				emit(&chunkJob{data: buf, result: make(chan chunkResult, 1)})
+			}
+			return nil
+		}
+
+		cut := bytes.LastIndexByte(buf, '\n') + 1
+		if cut == 0 {
+			// A full buffer without a line break starts a single line
+			if limit := c.opts.MaxLineLength + 2; len(buf) < limit {
+				grown := make([]byte, len(buf), min(2*cap(buf), limit))
+				copy(grown, buf)
+				buf = grown
+				continue
+			}
+
+			if !c.emitLongLine(reader, buf, done, emit) {
+				return nil
+			}
+			buf = make([]byte, 0, size)
+			continue
+		}
+
+		next := make([]byte, len(buf)-cut, max(size, 2*(len(buf)-cut)))
+		copy(next, buf[cut:])
+		if !emit(&chunkJob{data: buf[:cut], result: make(chan chunkResult, 1)}) {
+			return nil
+		}
+		buf = next
+	}
+}
+
+// emitLongLine streams a line that starts with first and does not fit into
+// a chunk to the writer, in order with the surrounding chunks
+func (c *Cleaner) emitLongLine(reader *bufio.Reader, first []byte, done <-chan struct{}, emit func(*chunkJob) bool) bool {
+	job := &chunkJob{pieces: make(chan []byte), result: make(chan chunkResult, 1)}
+	if !emit(job) {
+		return false
+	}
+
+	res := c.newChunkResult()
+	res.err = c.streamLongLine(reader, first, &pieceWriter{pieces: job.pieces, done: done}, &res.stats)
+	close(job.pieces)
+	job.result <- res
+
+	return res.err == nil
+}
+
+// pieceWriter hands copies of written data to the writer goroutine
+type pieceWriter struct {
+	pieces chan<- []byte
+	done   <-chan struct{}
+}
+
+func (w *pieceWriter) Write(p []byte) (int, error) {
+	select {
+	case w.pieces <- bytes.Clone(p):
+		return len(p), nil
+	case <-w.done:
+		return 0, errAborted
+	}
+}
+
+// newChunkResult returns a result whose stats are added to the run's in
+// input order
+func (c *Cleaner) newChunkResult() chunkResult {
+	var res chunkResult
+	if c.opts.Histogram {
+		res.stats.Histogram = histogram.New()
+	}
+	res.stats.removedLimit = c.opts.RemovedSamples
+	return res
+}
+
+// processChunk filters all lines of a chunk, producing the same output as
+// the sequential path would for those lines
+func (c *Cleaner) processChunk(data []byte, scratch *matchScratch) chunkResult {
+	res := c.newChunkResult()
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+
+	for len(data) > 0 {
+		raw := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			raw = data[:i+1]
+		}
+		data = data[len(raw):]
+
+		if err := c.filterLine(raw, scratch, &res.stats, out); err != nil {
+			res.err = err
+			return res
+		}
+	}
+
+	res.out = out.Bytes()
+	return res
+}
@@ -0,0 +1,114 @@
+package cleaner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestCleanParallelMatchesSequential(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	var sb strings.Builder
+	for i := 0; i < 500; i++ {
+		switch i % 7 {
+		case 0:
+			sb.WriteString(fmt.Sprintf("ERROR: failure %d\r\n", i))
+		case 3:
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(fmt.Sprintf("INFO: line %d %s\n", i, strings.Repeat("x", i%50)))
+		}
+	}
+	sb.WriteString("DEBUG: unterminated last line")
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f1, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+	f2, _ := filter.New("remove-odd", `line \d*[13579] `, filter.TypeRemove)
+	filters := []*filter.Filter{f1, f2}
+
+	seqPath := filepath.Join(tempDir, "sequential.log")
+	seqStats, err := New(filters).Clean(inputPath, seqPath, nil)
+	if err != nil {
+		t.Fatalf("sequential Clean() error = %v", err)
+	}
+	want, _ := os.ReadFile(seqPath)
+
+	for _, chunkSize := range []int{16, 100, 4096} {
+		t.Run(fmt.Sprintf("chunk=%d", chunkSize), func(t *testing.T) {
+			parPath := filepath.Join(tempDir, fmt.Sprintf("parallel-%d.log", chunkSize))
+			c := NewWithOptions(filters, Options{Workers: 4, ChunkSize: chunkSize})
+
+			stats, err := c.Clean(inputPath, parPath, nil)
+			if err != nil {
+				t.Fatalf("parallel Clean() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(stats, seqStats) {
+				t.Errorf("Stats differ: parallel %+v, sequential %+v", *stats, *seqStats)
+			}
+
+			got, _ := os.ReadFile(parPath)
+			if !bytes.Equal(got, want) {
+				t.Error("Parallel output differs from sequential output")
+			}
+		})
+	}
+}
+
+func TestCleanParallelMatchesSequential_DedupLongLines(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	// Repeated lines longer than MaxLineLength, of which some fit into a
+	// chunk and some do not
+	var sb strings.Builder
+	for i := 0; i < 300; i++ {
+		switch {
+		case i%100 == 1:
+			sb.WriteString("WARN: " + strings.Repeat("longer", 200) + "\n")
+		case i%5 == 0:
+			sb.WriteString("INFO: " + strings.Repeat("long", 75) + "\n")
+		default:
+			sb.WriteString(fmt.Sprintf("INFO: request %d done\n", i%3))
+		}
+	}
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []LongLinePolicy{LongLinePassThrough, LongLineTruncate} {
+		t.Run(string(policy), func(t *testing.T) {
+			opts := Options{MaxLineLength: 100, LongLines: policy, DedupWindow: 5}
+			var want bytes.Buffer
+			seqStats, err := NewWithOptions(nil, opts).CleanTo(inputPath, &want, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, workers := range []int{2, 4} {
+				opts.Workers, opts.ChunkSize = workers, 512
+				var got bytes.Buffer
+				stats, err := NewWithOptions(nil, opts).CleanTo(inputPath, &got, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Bytes(), want.Bytes()) {
+					t.Errorf("workers=%d: output differs from sequential output", workers)
+				}
+				if stats.DuplicateLines != seqStats.DuplicateLines {
+					t.Errorf("workers=%d: DuplicateLines = %d, sequential %d", workers, stats.DuplicateLines, seqStats.DuplicateLines)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package cleaner
+
+import "unicode/utf8"
+
+// maxSampleLength bounds the bytes kept of a removed line sample
+const maxSampleLength = 200
+
+// addRemoved keeps line as a sample of the lines removed by filter, unless
+// the filter has enough samples
+func (s *Stats) addRemoved(filter int, line string) {
+	if s.removedLimit <= 0 {
+		return
+	}
+	for len(s.Removed) <= filter {
+		s.Removed = append(s.Removed, nil)
+	}
+	if len(s.Removed[filter]) < s.removedLimit {
+		s.Removed[filter] = append(s.Removed[filter], line)
+	}
+}
+
+// sampleText returns line cut to maxSampleLength bytes on a rune boundary
+func sampleText(line []byte) string {
+	if len(line) <= maxSampleLength {
+		return string(line)
+	}
+	cut := maxSampleLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return string(line[:cut]) + "…"
+}
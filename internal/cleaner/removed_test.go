@@ -0,0 +1,52 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestClean_RemovedSamples(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "DEBUG step %d\n", i)
+		if i%50 == 0 {
+			fmt.Fprintf(&sb, "TRACE %s\n", strings.Repeat("x", 300))
+		}
+	}
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	debug, _ := filter.New("debug", "^DEBUG", filter.TypeRemove)
+	trace, _ := filter.New("trace", "^TRACE", filter.TypeRemove)
+	filters := []*filter.Filter{debug, trace}
+
+	want := [][]string{
+		{"DEBUG step 0", "DEBUG step 1", "DEBUG step 2"},
+		{"TRACE " + strings.Repeat("x", maxSampleLength-6) + "…", "TRACE " + strings.Repeat("x", maxSampleLength-6) + "…", "TRACE " + strings.Repeat("x", maxSampleLength-6) + "…"},
+	}
+	for _, workers := range []int{1, 4} {
+		c := NewWithOptions(filters, Options{Workers: workers, ChunkSize: 128, RemovedSamples: 3})
+		stats, err := c.CleanTo(inputPath, &strings.Builder{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(stats.Removed, want) {
+			t.Errorf("workers=%d: Removed = %q", workers, stats.Removed)
+		}
+	}
+
+	stats, _ := New(filters).CleanTo(inputPath, &strings.Builder{}, nil)
+	if stats.Removed != nil {
+		t.Errorf("Removed = %q without RemovedSamples", stats.Removed)
+	}
+}
@@ -0,0 +1,67 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sstreichan/logcleaner/internal/rotation"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+// CleanRotated cleans the members of a rotation set, ordered from oldest to
+// newest as returned by rotation.Discover, into one output. Gzipped members
+// are decompressed and each member is decoded from its own encoding; with
+// PreserveEncoding the output uses the encoding of the newest member.
+// Lines repeated at the start of a member are skipped and counted in
+// Stats.OverlapLines.
+func (c *Cleaner) CleanRotated(paths []string, w io.Writer, progressCb func(int, int)) (*Stats, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no input files")
+	}
+
+	detections := make([]textenc.Detection, len(paths))
+	for i, path := range paths {
+		rc, err := rotation.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+		detection, err := textenc.DetectReader(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect encoding of %s: %w", path, err)
+		}
+		detections[i] = c.overrideEncoding(detection)
+	}
+
+	join := func() *rotation.Joined {
+		return rotation.NewJoined(len(paths), func(i int) (io.ReadCloser, error) {
+			rc, err := rotation.Open(paths[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to open input file: %w", err)
+			}
+			return decodedReader{textenc.NewDecoder(rc, detections[i]), rc}, nil
+		})
+	}
+
+	if c.beginShapes(false) {
+		defer c.endShapes()
+		counted := join()
+		err := c.countShapes(counted)
+		counted.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	joined := join()
+	defer joined.Close()
+
+	stats, err := c.cleanStream(joined, detections[len(paths)-1], w, progressCb)
+	stats.OverlapLines = joined.Skipped()
+	return stats, err
+}
+
+type decodedReader struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,57 @@
+package cleaner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/rotation"
+)
+
+func TestCleanRotated(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.log")
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("INFO: day 1 Gr\xf6\xdfe\nERROR: day 1\n")) // Latin-1
+	zw.Close()
+
+	members := map[string][]byte{
+		"app.log.2.gz": gz.Bytes(),
+		"app.log.1":    []byte("INFO: day 2 a\nINFO: day 2 b\nERROR: day 2\n"),
+		// copytruncate left the last lines of app.log.1 in place
+		"app.log": []byte("INFO: day 2 b\nERROR: day 2\nINFO: day 3\n"),
+	}
+	for name, data := range members {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := rotation.Discover(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := filter.New("remove-errors", "^ERROR", filter.TypeRemove)
+	for _, workers := range []int{1, 4} {
+		var out bytes.Buffer
+		c := NewWithOptions([]*filter.Filter{f}, Options{Workers: workers, ChunkSize: 16})
+		stats, err := c.CleanRotated(paths, &out, nil)
+		if err != nil {
+			t.Fatalf("CleanRotated() error = %v", err)
+		}
+
+		want := "INFO: day 1 Größe\nINFO: day 2 a\nINFO: day 2 b\nINFO: day 3\n"
+		if out.String() != want {
+			t.Errorf("workers=%d: output = %q, want %q", workers, out.String(), want)
+		}
+		if stats.TotalLines != 6 || stats.FilteredLines != 2 || stats.OverlapLines != 2 {
+			t.Errorf("workers=%d: unexpected stats %+v", workers, *stats)
+		}
+	}
+}
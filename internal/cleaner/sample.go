@@ -0,0 +1,90 @@
+package cleaner
+
+import (
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/logtime"
+)
+
+// maxLimitedShapes bounds the shapes a rate limit tracks per interval;
+// lines of further shapes are kept
+const maxLimitedShapes = 10000
+
+// limiter is the state of the rate-limited sample filters during a run.
+// Lines are put into intervals by their timestamp; lines without one, such
+// as continuation lines, belong to the interval of the last timestamp.
+type limiter struct {
+	parser logtime.Parser
+	last   time.Time
+	// buckets holds, per filter index, the counts of its current interval
+	buckets map[int]*limitBucket
+}
+
+type limitBucket struct {
+	interval int64
+	counts   map[string]int
+}
+
+// beginSampling prepares a run of the sample filters; endSampling must be
+// called after the run. Rate limits need lines in order, so a Cleaner
+// with rate-limited filters processes its input sequentially.
+func (c *Cleaner) beginSampling() {
+	if c.rateLimited {
+		c.limiter = &limiter{buckets: make(map[int]*limitBucket)}
+	}
+}
+
+func (c *Cleaner) endSampling() {
+	c.limiter = nil
+}
+
+// observe notes the timestamp of line, if it has one
+func (l *limiter) observe(line []byte) {
+	if t, ok := l.parser.Parse(line); ok {
+		l.last = t
+	}
+}
+
+// allow counts a line of shape for filter fi and reports whether it is
+// within the filter's limit for the current interval
+func (l *limiter) allow(fi, limit int, interval time.Duration, shape []byte) bool {
+	var n int64
+	if interval > 0 {
+		n = l.last.UnixNano() / int64(interval)
+	}
+
+	b := l.buckets[fi]
+	if b == nil || b.interval != n {
+		b = &limitBucket{interval: n, counts: make(map[string]int)}
+		l.buckets[fi] = b
+	}
+
+	count, ok := b.counts[string(shape)]
+	if !ok && len(b.counts) == maxLimitedShapes {
+		return true
+	}
+	if count >= limit {
+		return false
+	}
+	b.counts[string(shape)] = count + 1
+	return true
+}
+
+// sampled reports whether a line is among the one in rate kept by a
+// sample filter. The choice depends on the content only, so reruns keep
+// the same lines and identical lines share their fate.
+func sampled(line []byte, rate int) bool {
+	// FNV-1a, finished with the splitmix64 mixer so that the low bits used
+	// for the modulus depend on every byte
+	h := uint64(14695981039346656037)
+	for _, b := range line {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h%uint64(rate) == 0
+}
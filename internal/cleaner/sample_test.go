@@ -0,0 +1,105 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestClean_Sample(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "DEBUG request %d handled\n", i)
+	}
+	sb.WriteString("ERROR disk full\n")
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	debug, err := filter.NewSample("debug", "^DEBUG", 10, filter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first string
+	for _, workers := range []int{1, 4} {
+		outputPath := filepath.Join(tempDir, fmt.Sprintf("output%d.log", workers))
+		c := NewWithOptions([]*filter.Filter{debug}, Options{Workers: workers, ChunkSize: 256})
+		stats, err := c.Clean(inputPath, outputPath, nil)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+
+		output, _ := os.ReadFile(outputPath)
+		if first == "" {
+			first = string(output)
+		} else if string(output) != first {
+			t.Errorf("workers=%d kept other lines than a sequential run", workers)
+		}
+
+		if !strings.HasSuffix(string(output), "ERROR disk full\n") {
+			t.Errorf("lines not matching the pattern should be kept")
+		}
+		kept := stats.RemainingLines() - 1
+		if kept < 60 || kept > 140 {
+			t.Errorf("kept %d of 1000 lines, want about 100", kept)
+		}
+		if stats.SampledOut != 1000-kept || stats.FilteredLines != stats.SampledOut {
+			t.Errorf("SampledOut = %d, FilteredLines = %d, kept %d", stats.SampledOut, stats.FilteredLines, kept)
+		}
+	}
+}
+
+func TestClean_RateLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "input.log")
+
+	// Five requests and one error per minute; the stack trace line has no
+	// timestamp and belongs to the minute of its error
+	var sb strings.Builder
+	for minute := 0; minute < 3; minute++ {
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(&sb, "2024-01-01T10:%02d:%02dZ GET /api took %dms\n", minute, i*10, i+1)
+		}
+		fmt.Fprintf(&sb, "2024-01-01T10:%02d:55Z ERROR timeout\n", minute)
+		sb.WriteString("  at handler.go:42\n")
+	}
+	if err := os.WriteFile(inputPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	limit, err := filter.NewRateLimit("limit", "", 2, time.Minute, filter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var out strings.Builder
+			c := NewWithOptions([]*filter.Filter{limit}, Options{Workers: workers, ChunkSize: 64})
+			stats, err := c.CleanTo(inputPath, &out, nil)
+			if err != nil {
+				t.Fatalf("CleanTo() error = %v", err)
+			}
+
+			// Two requests, the error and the trace line of each minute
+			if got := strings.Count(out.String(), "GET /api"); got != 6 {
+				t.Errorf("kept %d requests, want 2 per minute:\n%s", got, out.String())
+			}
+			if strings.Count(out.String(), "ERROR") != 3 || strings.Count(out.String(), "handler.go") != 3 {
+				t.Errorf("rare lines should be kept:\n%s", out.String())
+			}
+			if stats.SampledOut != 9 {
+				t.Errorf("SampledOut = %d, want 9", stats.SampledOut)
+			}
+		})
+	}
+}
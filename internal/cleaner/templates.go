@@ -0,0 +1,80 @@
+package cleaner
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/sstreichan/logcleaner/internal/drain"
+)
+
+// maxMinedLine is the longest line fed to the template miner; longer lines
+// are not mined
+const maxMinedLine = 4096
+
+// templateMiner passes writes through to w and feeds each complete kept
+// line to a Drain miner. It only passes writes through with Templates off.
+type templateMiner struct {
+	w       io.Writer
+	miner   *drain.Miner
+	partial []byte
+	skip    bool // the line in progress is too long to mine
+}
+
+func (c *Cleaner) newTemplateMiner(w io.Writer) *templateMiner {
+	t := &templateMiner{w: w}
+	if c.opts.Templates > 0 {
+		t.miner = drain.New(drain.Options{})
+	}
+	return t
+}
+
+func (t *templateMiner) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	if t.miner == nil {
+		return n, err
+	}
+
+	data := p[:n]
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.append(data)
+			break
+		}
+		t.append(data[:i])
+		if !t.skip {
+			t.miner.Add(trimCR(t.partial))
+		}
+		t.partial, t.skip = t.partial[:0], false
+		data = data[i+1:]
+	}
+	return n, err
+}
+
+func (t *templateMiner) append(data []byte) {
+	if t.skip {
+		return
+	}
+	if len(t.partial)+len(data) > maxMinedLine {
+		t.partial, t.skip = t.partial[:0], true
+		return
+	}
+	t.partial = append(t.partial, data...)
+}
+
+// top mines an unterminated last line and returns the n largest templates
+func (t *templateMiner) top(n int) []drain.Template {
+	if t.miner == nil {
+		return nil
+	}
+	if len(t.partial) > 0 && !t.skip {
+		t.miner.Add(trimCR(t.partial))
+		t.partial = t.partial[:0]
+	}
+	return t.miner.Top(n)
+}
+
+// trimCR drops the \r of a CRLF line whose \n is already cut off
+func trimCR(line []byte) []byte {
+	return bytes.TrimSuffix(line, []byte{'\r'})
+}
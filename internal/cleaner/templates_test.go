@@ -0,0 +1,60 @@
+package cleaner
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+func TestClean_Templates(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 300; i++ {
+		switch i % 3 {
+		case 0:
+			fmt.Fprintf(&sb, "GET /users/%d took %dms\n", i, i%17)
+		case 1:
+			fmt.Fprintf(&sb, "DEBUG: tick %d\n", i)
+		default:
+			fmt.Fprintf(&sb, "cache miss for key%d\r\n", i)
+		}
+	}
+	sb.WriteString("cache miss for key9")
+	input := sb.String()
+
+	f, _ := filter.New("remove-debug", "^DEBUG", filter.TypeRemove)
+	want := []string{"101 cache miss for <*>", "100 GET <*> took <*>"}
+
+	for _, workers := range []int{1, 4} {
+		c := NewWithOptions([]*filter.Filter{f}, Options{Workers: workers, ChunkSize: 64, Templates: 5})
+		stats, err := c.cleanStream(strings.NewReader(input), textenc.Detection{}, &bytes.Buffer{}, nil)
+		if err != nil {
+			t.Fatalf("cleanStream() error = %v", err)
+		}
+
+		var got []string
+		for _, tmpl := range stats.Templates {
+			got = append(got, fmt.Sprintf("%d %s", tmpl.Count, tmpl))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d Templates = %q, want %q", workers, got, want)
+		}
+	}
+}
+
+func TestClean_TemplatesCRLF(t *testing.T) {
+	input := "job 1 done\r\njob 2 done\njob 3 done\r\njob 4 done\r"
+
+	c := NewWithOptions(nil, Options{Templates: 5})
+	stats, err := c.cleanStream(strings.NewReader(input), textenc.Detection{}, &bytes.Buffer{}, nil)
+	if err != nil {
+		t.Fatalf("cleanStream() error = %v", err)
+	}
+	if len(stats.Templates) != 1 || stats.Templates[0].Count != 4 || stats.Templates[0].String() != "job <*> done" {
+		t.Errorf("Templates = %q, want one without the \\r", stats.Templates)
+	}
+}
@@ -0,0 +1,561 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/discover"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/manifest"
+	"github.com/sstreichan/logcleaner/internal/output"
+	"github.com/sstreichan/logcleaner/internal/report"
+	"github.com/sstreichan/logcleaner/internal/rotation"
+	"github.com/sstreichan/logcleaner/internal/storage"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+// Run executes logcleaner without the TUI and returns the exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	if err := run(args, stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errFlags) {
+			return 1
+		}
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// errFlags is returned for invalid flags, which the flag package already
+// reported along with the usage
+var errFlags = errors.New("invalid flags")
+
+// parseFlags parses args into fs, leaving the report of errors to fs
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errFlags
+	}
+	return nil
+}
+
+type config struct {
+	follow           bool
+	fromStart        bool
+	rotated          bool
+	merge            bool
+	label            bool
+	reorderWindow    int
+	inPlace          bool
+	backup           bool
+	output           string
+	template         string
+	outputDir        string
+	force            bool
+	workers          int
+	maxLine          int
+	longLines        string
+	encoding         string
+	preserveEncoding bool
+	dedup            bool
+	dedupWindow      int
+	dedupExact       bool
+	templates        int
+	report           string
+	manifest         bool
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "verify" {
+		return runVerify(args[1:], stdout, stderr)
+	}
+
+	var cfg config
+	fs := flag.NewFlagSet("logcleaner", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: logcleaner [flags] <file|directory|pattern>...")
+		fmt.Fprintln(stderr, "       logcleaner verify <manifest|output>...")
+		fmt.Fprintln(stderr, "Without arguments the interactive interface is started.")
+		fs.PrintDefaults()
+	}
+
+	fs.BoolVar(&cfg.follow, "f", false, "follow the file as it grows, like tail -f")
+	fs.BoolVar(&cfg.follow, "follow", false, "same as -f")
+	fs.BoolVar(&cfg.fromStart, "from-start", false, "with -f, clean the existing content first")
+	fs.BoolVar(&cfg.rotated, "rotated", false, "merge the rotation set of the file (file.1, file.2.gz, ...) into one output")
+	fs.BoolVar(&cfg.merge, "merge", false, "interleave all inputs by timestamp into one output (default merged.log.cleaned)")
+	fs.BoolVar(&cfg.label, "label", false, "with -merge, prefix each line with the name of its file")
+	fs.IntVar(&cfg.reorderWindow, "reorder-window", 0, "with -merge, lines per file buffered to fix slightly out-of-order timestamps (default 1000)")
+	fs.BoolVar(&cfg.inPlace, "in-place", false, "replace each input with its cleaned content, keeping permissions, owner and mtime; stop processes that keep it open first, their later writes go to the replaced file")
+	fs.BoolVar(&cfg.backup, "backup", false, "with -in-place, keep the original as <file>.bak")
+	fs.StringVar(&cfg.output, "o", "", "output file, - for stdout (default from -template)")
+	fs.StringVar(&cfg.template, "template", "", "output path template with {dir}, {name}, {ext}, {date} and {profile} (default {dir}/{name}{ext}.cleaned)")
+	fs.StringVar(&cfg.outputDir, "output-dir", "", "directory for outputs instead of the input's directory")
+	fs.BoolVar(&cfg.force, "force", false, "overwrite existing output files")
+	fs.IntVar(&cfg.workers, "workers", runtime.NumCPU(), "number of parallel workers")
+	fs.IntVar(&cfg.maxLine, "max-line", 0, "longest line in bytes that is buffered in full (default 1 MiB)")
+	fs.StringVar(&cfg.longLines, "long-lines", string(cleaner.LongLinePassThrough), "policy for longer lines: pass, truncate or drop")
+	fs.StringVar(&cfg.encoding, "encoding", "", "input encoding instead of the detected one")
+	fs.BoolVar(&cfg.preserveEncoding, "preserve-encoding", false, "write the output in the input encoding; with -merge all inputs must share it")
+	fs.BoolVar(&cfg.dedup, "dedup", false, "collapse consecutive repeats of a line, ignoring timestamps, numbers, IDs and IPs")
+	fs.IntVar(&cfg.dedupWindow, "dedup-window", 0, "collapse repeats up to this many distinct lines apart (implies -dedup)")
+	fs.BoolVar(&cfg.dedupExact, "dedup-exact", false, "compare lines byte for byte when collapsing repeats (implies -dedup)")
+	fs.IntVar(&cfg.templates, "templates", 0, "list the most frequent message templates of the kept lines")
+	fs.StringVar(&cfg.report, "report", "", "write a Markdown (.md) or HTML (.html) report of the run to this file")
+	fs.BoolVar(&cfg.manifest, "manifest", true, "write <output>.manifest.json with hashes, filters, options and stats next to each output file")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("expected an input file")
+	}
+
+	if cfg.rotated && (cfg.follow || fs.NArg() > 1) {
+		return fmt.Errorf("-rotated needs exactly one input file and cannot be used with -f")
+	}
+
+	// The base name of a rotation set may itself have been rotated away
+	inputs := fs.Args()
+	if !cfg.rotated {
+		inputs = nil
+		for _, arg := range fs.Args() {
+			files, err := discover.Expand(arg)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, files...)
+		}
+	}
+
+	store, err := storage.New()
+	if err != nil {
+		return err
+	}
+	namer, err := cfg.namer(store)
+	if err != nil {
+		return err
+	}
+	if !cfg.rotated {
+		// Arguments may overlap, and outputs of an earlier run with another
+		// template than .cleaned are not skipped by Expand
+		inputs = namer.WithoutOutputs(discover.Unique(inputs))
+	}
+	if cfg.inPlace && (cfg.follow || cfg.rotated || cfg.merge || cfg.output != "") {
+		return fmt.Errorf("-in-place cannot be used with -f, -rotated, -merge or -o")
+	}
+	if cfg.merge && (cfg.follow || cfg.rotated) {
+		return fmt.Errorf("-merge cannot be used with -f or -rotated")
+	}
+	if len(inputs) > 1 && !cfg.merge && (cfg.follow || cfg.output != "") {
+		return fmt.Errorf("-f and -o need exactly one input file, got %d", len(inputs))
+	}
+	if cfg.report != "" {
+		if cfg.follow || cfg.inPlace {
+			return fmt.Errorf("-report cannot be used with -f or -in-place")
+		}
+		if _, err := report.FormatFor(cfg.report); err != nil {
+			return err
+		}
+	}
+	inputPath := inputs[0]
+
+	opts, err := cfg.cleanerOptions()
+	if err != nil {
+		return err
+	}
+
+	filters, err := store.Load()
+	if err != nil {
+		return err
+	}
+	c := cleaner.NewWithOptions(filters, opts)
+	recorded := manifest.NewOptions(opts)
+
+	if cfg.inPlace {
+		recorded.Backup = cfg.backup
+		return cfg.cleanInPlace(c, filters, recorded, inputs, stderr)
+	}
+
+	if len(inputs) > 1 && !cfg.merge {
+		outputs := make(map[string]string, len(inputs))
+		paths := make([]string, len(inputs))
+		for i, input := range inputs {
+			paths[i] = namer.Path(input)
+			outputs[input] = paths[i]
+		}
+		if a, b, out, ok := output.Collision(inputs, paths); ok {
+			return fmt.Errorf("%s and %s would both be written to %s, clean them separately", a, b, out)
+		}
+		if err := cfg.checkOverwrite(inputs, paths); err != nil {
+			return err
+		}
+		result, err := cfg.cleanBatch(c, filters, recorded, inputs, outputs, stderr)
+		if result != nil {
+			var cleaned []string
+			for _, res := range result.Files {
+				if res.Err == nil {
+					cleaned = append(cleaned, res.OutputPath)
+				}
+			}
+			if reportErr := cfg.writeReport(filters, inputs, cleaned, &result.Total, stderr); err == nil {
+				err = reportErr
+			}
+		}
+		return err
+	}
+
+	// A rotation set is read in full, so none of its files may be the output
+	reads := inputs
+	if cfg.rotated {
+		reads, err = rotation.Discover(inputPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var out io.Writer = stdout
+	var outputs []string
+	if cfg.output != "-" {
+		outputPath := cfg.output
+		if outputPath == "" {
+			outputPath = namer.Path(inputPath)
+		}
+		if cfg.output == "" && cfg.merge {
+			outputPath = namer.MergedPath(inputs)
+		}
+		if err := cfg.checkOverwrite(reads, []string{outputPath}); err != nil {
+			return err
+		}
+		if cfg.output == "" {
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+		}
+
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if cfg.follow {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		file, err := os.OpenFile(outputPath, flags, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+		outputs = append(outputs, outputPath)
+	}
+
+	if cfg.merge {
+		opts := cleaner.MergeOptions{ReorderWindow: cfg.reorderWindow}
+		if cfg.label {
+			for _, input := range inputs {
+				opts.Labels = append(opts.Labels, filepath.Base(input))
+			}
+		}
+		recorded.Labels = opts.Labels
+		recorded.ReorderWindow = opts.ReorderWindow
+		m, err := cfg.beginManifest(manifest.ModeMerge, inputs, outputs, filters, recorded)
+		if err != nil {
+			return err
+		}
+		stats, err := c.CleanMerged(inputs, out, opts, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "%d files merged\n", len(inputs))
+		printSummary(stderr, stats)
+		if err := finishManifest(m, outputs, stats, stderr); err != nil {
+			return err
+		}
+		return cfg.writeReport(filters, inputs, outputs, stats, stderr)
+	}
+
+	if cfg.rotated {
+		paths := reads
+		m, err := cfg.beginManifest(manifest.ModeRotated, paths, outputs, filters, recorded)
+		if err != nil {
+			return err
+		}
+		stats, err := c.CleanRotated(paths, out, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "%d files merged, %d overlapping lines skipped\n", len(paths), stats.OverlapLines)
+		printSummary(stderr, stats)
+		if err := finishManifest(m, outputs, stats, stderr); err != nil {
+			return err
+		}
+		return cfg.writeReport(filters, paths, outputs, stats, stderr)
+	}
+
+	if !cfg.follow {
+		m, err := cfg.beginManifest(manifest.ModeClean, inputs, outputs, filters, recorded)
+		if err != nil {
+			return err
+		}
+		stats, err := c.CleanTo(inputPath, out, nil)
+		if err != nil {
+			return err
+		}
+		printSummary(stderr, stats)
+		if err := finishManifest(m, outputs, stats, stderr); err != nil {
+			return err
+		}
+		return cfg.writeReport(filters, inputs, outputs, stats, stderr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := c.Follow(ctx, inputPath, out, cleaner.FollowOptions{FromStart: cfg.fromStart})
+	if err != nil {
+		return err
+	}
+	printSummary(stderr, stats)
+	return nil
+}
+
+// cleanBatch cleans each input to its path in outputs, reporting all
+// failures at the end instead of stopping at the first one
+func (cfg config) cleanBatch(c *cleaner.Cleaner, filters []*filter.Filter, recorded manifest.Options, inputs []string, outputs map[string]string, stderr io.Writer) (*cleaner.BatchResult, error) {
+	manifests := make(map[string]*manifest.Manifest, len(inputs))
+	for _, input := range inputs {
+		output := outputs[input]
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		m, err := cfg.beginManifest(manifest.ModeClean, []string{input}, []string{output}, filters, recorded)
+		if err != nil {
+			return nil, err
+		}
+		manifests[input] = m
+	}
+
+	result := c.CleanFiles(inputs, func(input string) string {
+		return outputs[input]
+	}, nil)
+
+	for i := range result.Files {
+		res := &result.Files[i]
+		if res.Err == nil {
+			res.Err = finishManifest(manifests[res.InputPath], []string{res.OutputPath}, res.Stats, io.Discard)
+		}
+		if res.Err == nil {
+			fmt.Fprintf(stderr, "%s: ", res.InputPath)
+			printSummary(stderr, res.Stats)
+		}
+	}
+	fmt.Fprint(stderr, "Total: ")
+	printSummary(stderr, &result.Total)
+
+	failed := result.Failed()
+	if len(failed) == 0 {
+		return result, nil
+	}
+	for _, res := range failed {
+		fmt.Fprintf(stderr, "Failed: %s: %v\n", res.InputPath, res.Err)
+	}
+	return result, fmt.Errorf("%d of %d files failed", len(failed), len(result.Files))
+}
+
+// cleanInPlace replaces each input with its cleaned content, continuing
+// past files that fail
+func (cfg config) cleanInPlace(c *cleaner.Cleaner, filters []*filter.Filter, recorded manifest.Options, inputs []string, stderr io.Writer) error {
+	failed := 0
+	for _, input := range inputs {
+		stats, err := cfg.cleanFileInPlace(c, filters, recorded, input)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed: %s: %v\n", input, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stderr, "%s: ", input)
+		printSummary(stderr, stats)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(inputs))
+	}
+	return nil
+}
+
+// cleanFileInPlace cleans one input in place and writes its manifest
+func (cfg config) cleanFileInPlace(c *cleaner.Cleaner, filters []*filter.Filter, recorded manifest.Options, input string) (*cleaner.Stats, error) {
+	m, err := cfg.beginManifest(manifest.ModeInPlace, []string{input}, []string{input}, filters, recorded)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := c.CleanInPlace(input, cleaner.InPlaceOptions{Backup: cfg.backup}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return stats, finishManifest(m, []string{input}, stats, io.Discard)
+}
+
+// namer names outputs from the flags, falling back to the saved settings
+func (cfg config) namer(store *storage.Storage) (*output.Namer, error) {
+	settings, err := store.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	template, dir := settings.OutputTemplate, settings.OutputDir
+	if cfg.template != "" {
+		template = cfg.template
+	}
+	if cfg.outputDir != "" {
+		dir = cfg.outputDir
+	}
+	return output.NewNamer(template, dir, settings.Profile)
+}
+
+// checkOverwrite refuses outputs that are one of the inputs, and unless
+// -force or -f is set outputs that exist
+func (cfg config) checkOverwrite(inputs, outputs []string) error {
+	if out, in, ok := output.Overwritten(outputs, inputs); ok {
+		if len(inputs) == 1 {
+			return fmt.Errorf("output %s would overwrite its input, use -in-place instead", out)
+		}
+		return fmt.Errorf("output %s would overwrite the input %s", out, in)
+	}
+	// Following appends, so an existing output is expected
+	if cfg.force || cfg.follow {
+		return nil
+	}
+
+	existing := output.Existing(outputs)
+	if len(existing) == 0 {
+		return nil
+	}
+	sort.Strings(existing)
+	if len(existing) == 1 {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", existing[0])
+	}
+	return fmt.Errorf("%d output files already exist (first: %s), use -force to overwrite them", len(existing), existing[0])
+}
+
+func (cfg config) cleanerOptions() (cleaner.Options, error) {
+	opts := cleaner.Options{
+		Workers:          cfg.workers,
+		MaxLineLength:    cfg.maxLine,
+		LongLines:        cleaner.LongLinePolicy(cfg.longLines),
+		PreserveEncoding: cfg.preserveEncoding,
+		DedupWindow:      cfg.dedupWindow,
+		DedupExact:       cfg.dedupExact,
+		Templates:        cfg.templates,
+	}
+	if cfg.report != "" {
+		opts.Histogram = true
+		opts.RemovedSamples = report.Samples
+	}
+	if opts.DedupWindow < 0 {
+		return opts, fmt.Errorf("-dedup-window must not be negative")
+	}
+	if opts.DedupWindow == 0 && (cfg.dedup || cfg.dedupExact) {
+		opts.DedupWindow = 1
+	}
+
+	switch opts.LongLines {
+	case cleaner.LongLinePassThrough, cleaner.LongLineTruncate, cleaner.LongLineDrop:
+	default:
+		return opts, fmt.Errorf("unsupported long line policy: %s", cfg.longLines)
+	}
+
+	if cfg.encoding != "" {
+		enc, err := textenc.Parse(cfg.encoding)
+		if err != nil {
+			return opts, err
+		}
+		opts.Encoding = enc
+	}
+
+	return opts, nil
+}
+
+// beginManifest hashes the inputs of a run for the manifest of its output.
+// It returns nil when manifests are off or the run does not write a file.
+func (cfg config) beginManifest(mode manifest.Mode, inputs, outputs []string, filters []*filter.Filter, recorded manifest.Options) (*manifest.Manifest, error) {
+	if !cfg.manifest || cfg.follow || len(outputs) != 1 {
+		return nil, nil
+	}
+	return manifest.Begin(mode, inputs, filters, recorded)
+}
+
+// finishManifest writes the manifest begun for a run, if any
+func finishManifest(m *manifest.Manifest, outputs []string, stats *cleaner.Stats, stderr io.Writer) error {
+	if m == nil {
+		return nil
+	}
+	if err := m.Finish(outputs[0], stats); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Manifest written to %s\n", manifest.Path(outputs[0]))
+	return nil
+}
+
+// writeReport writes the report asked for with -report, if any
+func (cfg config) writeReport(filters []*filter.Filter, inputs, outputs []string, stats *cleaner.Stats, stderr io.Writer) error {
+	if cfg.report == "" {
+		return nil
+	}
+
+	r := &report.Report{
+		Generated: time.Now(),
+		Inputs:    report.Stat(inputs...),
+		Outputs:   report.Stat(outputs...),
+		Filters:   filters,
+		Stats:     stats,
+	}
+	if err := report.WriteFile(cfg.report, r); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Report written to %s\n", cfg.report)
+	return nil
+}
+
+// suppressedShapes is the number of shapes removed by frequency filters
+// listed in the summary
+const suppressedShapes = 10
+
+func printSummary(w io.Writer, stats *cleaner.Stats) {
+	if stats.DuplicateLines > 0 {
+		fmt.Fprintf(w, "%d lines read, %d filtered, %d repeats collapsed, %d remaining\n",
+			stats.TotalLines, stats.FilteredLines, stats.DuplicateLines, stats.RemainingLines())
+	} else {
+		fmt.Fprintf(w, "%d lines read, %d filtered, %d remaining\n",
+			stats.TotalLines, stats.FilteredLines, stats.RemainingLines())
+	}
+
+	if stats.SampledOut > 0 {
+		fmt.Fprintf(w, "%d of the filtered lines were sampled out\n", stats.SampledOut)
+	}
+
+	for _, tmpl := range stats.Templates {
+		fmt.Fprintf(w, "%10d  %s\n", tmpl.Count, tmpl)
+	}
+
+	if suppressed := stats.TopSuppressed(suppressedShapes); len(suppressed) > 0 {
+		fmt.Fprintln(w, "Suppressed by frequency filters:")
+		for _, shape := range suppressed {
+			fmt.Fprintf(w, "%10d  %s\n", shape.Count, shape.Shape)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupFilters(t *testing.T, filters string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	configDir := filepath.Join(home, ".config", "logcleaner")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "filters.json"), []byte(filters), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	setupFilters(t, `[{"name":"remove-errors","pattern":"^ERROR","type":"remove"}]`)
+
+	inputPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(inputPath, []byte("INFO: a\nERROR: b\nINFO: c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stdout", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := Run([]string{"-o", "-", inputPath}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+		}
+		if got := stdout.String(); got != "INFO: a\nINFO: c\n" {
+			t.Errorf("stdout = %q", got)
+		}
+	})
+
+	t.Run("dedup", func(t *testing.T) {
+		repeatedPath := filepath.Join(t.TempDir(), "repeated.log")
+		if err := os.WriteFile(repeatedPath, []byte("INFO: job 1 done\nERROR: b\nINFO: job 2 done\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var stdout, stderr bytes.Buffer
+		if code := Run([]string{"-dedup", "-o", "-", repeatedPath}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+		}
+		if got := stdout.String(); got != "INFO: job 1 done [repeated 1 times]\n" {
+			t.Errorf("stdout = %q", got)
+		}
+		if !strings.Contains(stderr.String(), "1 repeats collapsed") {
+			t.Errorf("stderr = %q, want the collapsed count", stderr.String())
+		}
+	})
+
+	t.Run("default output", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := Run([]string{inputPath}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+		}
+		got, err := os.ReadFile(inputPath + ".cleaned")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "INFO: a\nINFO: c\n" {
+			t.Errorf("output = %q", got)
+		}
+	})
+}
+
+func TestRun_Frequency(t *testing.T) {
+	setupFilters(t, `[{"name":"noise","pattern":"","type":"frequency","threshold":2}]`)
+
+	inputPath := filepath.Join(t.TempDir(), "app.log")
+	content := "job 1 done\njob 2 done\ndisk full\njob 3 done\n"
+	if err := os.WriteFile(inputPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-o", "-", inputPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if got := stdout.String(); got != "disk full\n" {
+		t.Errorf("stdout = %q", got)
+	}
+	if !strings.Contains(stderr.String(), "3  job <NUM> done") {
+		t.Errorf("stderr = %q, want the suppressed shape", stderr.String())
+	}
+}
+
+func TestRun_Report(t *testing.T) {
+	setupFilters(t, `[{"name":"remove-errors","pattern":"^ERROR","type":"remove"}]`)
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "app.log")
+	content := "INFO started\nERROR disk full\nINFO done\n"
+	if err := os.WriteFile(inputPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reportPath := filepath.Join(dir, "run.md")
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-report", reportPath, inputPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+
+	got, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"app.log.cleaned", "remove-errors", "ERROR disk full"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("report does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRun_ManifestVerify(t *testing.T) {
+	setupFilters(t, `[{"name":"remove-errors","pattern":"^ERROR","type":"remove"}]`)
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(inputPath, []byte("INFO started\nERROR disk full\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{inputPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	outputPath := inputPath + ".cleaned"
+	if _, err := os.Stat(outputPath + ".manifest.json"); err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"verify", outputPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("verify = %d, stderr %q", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "OK ") {
+		t.Errorf("verify stdout = %q", stdout.String())
+	}
+
+	if err := os.WriteFile(outputPath, []byte("INFO started\nERROR disk full\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := Run([]string{"verify", outputPath + ".manifest.json"}, &stdout, &stderr); code != 1 {
+		t.Errorf("verify of a changed output = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "has changed") {
+		t.Errorf("verify stderr = %q", stderr.String())
+	}
+
+	// Without a manifest there is nothing to verify
+	other := filepath.Join(dir, "other.log")
+	if err := os.WriteFile(other, []byte("INFO x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := Run([]string{"-manifest=false", other}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if code := Run([]string{"verify", other + ".cleaned"}, &stdout, &stderr); code != 1 {
+		t.Errorf("verify without manifest = %d, want 1", code)
+	}
+}
+
+func TestRun_Batch(t *testing.T) {
+	setupFilters(t, `[{"name":"remove-errors","pattern":"^ERROR","type":"remove"}]`)
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("INFO: "+name+"\nERROR: x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{filepath.Join(dir, "*.log")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	for _, name := range []string{"a.log", "b.log"} {
+		got, err := os.ReadFile(filepath.Join(dir, name+".cleaned"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "INFO: "+name+"\n" {
+			t.Errorf("%s output = %q", name, got)
+		}
+	}
+
+	// Running again must not pick up the cleaned outputs
+	stderr.Reset()
+	if code := Run([]string{"-force", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Total: 4 lines read, 2 filtered") {
+		t.Errorf("stderr = %q, want the total of both files", stderr.String())
+	}
+
+	// Equally named files would share one output in an output directory
+	other := filepath.Join(dir, "other")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(other, "a.log"), []byte("INFO: other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(dir, "out")
+	stderr.Reset()
+	if code := Run([]string{"-output-dir", outDir, filepath.Join(dir, "a.log"), filepath.Join(other, "a.log")}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "would both be written to") {
+		t.Errorf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Errorf("output directory created before the check: %v", err)
+	}
+}
+
+func TestRun_OutputTemplate(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	inputPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(inputPath, []byte("INFO: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(t.TempDir(), "out")
+	args := []string{"-template", "{dir}/{profile}/{name}.clean{ext}", "-output-dir", outDir, inputPath}
+	want := filepath.Join(outDir, "default", "app.clean.log")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Fatalf("output not written: %v", err)
+	}
+
+	// An existing output is only replaced with -force
+	stderr.Reset()
+	if code := Run(args, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "-force") {
+		t.Errorf("Run() = %d, stderr %q, want refusal to overwrite", code, stderr.String())
+	}
+	if code := Run(append([]string{"-force"}, args...), &stdout, &stderr); code != 0 {
+		t.Errorf("Run(-force) = %d, stderr %q", code, stderr.String())
+	}
+
+	// A template naming the input itself never overwrites it
+	if code := Run([]string{"-force", "-template", "{dir}/{name}{ext}", inputPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("Run() = %d, want 1 for an output equal to the input", code)
+	}
+}
+
+func TestRun_InvalidArguments(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	tests := [][]string{
+		{},
+		{"-long-lines", "wrap", "app.log"},
+		{"-encoding", "ebcdic", "app.log"},
+		{"-unknown", "app.log"},
+		{"missing.log"},
+		{"-report", "run.txt", "app.log"},
+		{"-report", "run.md", "-f", "app.log"},
+	}
+
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("Run(%q) = %d, want 1", args, code)
+		}
+	}
+}
+
+func TestRun_Rotated(t *testing.T) {
+	setupFilters(t, `[{"name":"remove-errors","pattern":"^ERROR","type":"remove"}]`)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(base+".1", []byte("INFO: old\nERROR: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base, []byte("INFO: new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-rotated", "-o", "-", base}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if got := stdout.String(); got != "INFO: old\nINFO: new\n" {
+		t.Errorf("stdout = %q", got)
+	}
+
+	// A member of the set is refused as the output, even with -force
+	if code := Run([]string{"-rotated", "-force", "-o", base + ".1", base}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "would overwrite the input") {
+		t.Errorf("Run() into a rotated file = %d, stderr %q", code, stderr.String())
+	}
+	if got, _ := os.ReadFile(base + ".1"); string(got) != "INFO: old\nERROR: x\n" {
+		t.Errorf("rotated file = %q", got)
+	}
+}
+
+func TestRun_Merge(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.log"), []byte("2024-01-31 10:00:00 a1\n2024-01-31 10:00:02 a2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.log"), []byte("2024-01-31 10:00:01 b1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-merge", "-label", filepath.Join(dir, "*.log")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "merged.log.cleaned"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[a.log] 2024-01-31 10:00:00 a1\n[b.log] 2024-01-31 10:00:01 b1\n[a.log] 2024-01-31 10:00:02 a2\n"
+	if string(got) != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Any of the inputs is refused as the output, even with -force
+	b := filepath.Join(dir, "b.log")
+	if code := Run([]string{"-merge", "-force", "-o", b, filepath.Join(dir, "a.log"), b}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "would overwrite the input") {
+		t.Errorf("Run() merging into an input = %d, stderr %q", code, stderr.String())
+	}
+	if got, _ := os.ReadFile(b); string(got) != "2024-01-31 10:00:01 b1\n" {
+		t.Errorf("input = %q", got)
+	}
+}
+
+func TestRun_InPlace(t *testing.T) {
+	setupFilters(t, `[{"name":"remove-errors","pattern":"^ERROR","type":"remove"}]`)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("INFO: a\nERROR: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-in-place", "-backup", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "INFO: a\n" {
+		t.Errorf("content = %q", got)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("backup missing: %v", err)
+	}
+
+	if code := Run([]string{"-in-place", "-o", "out.log", path}, &stdout, &stderr); code != 1 {
+		t.Errorf("Run() with -in-place and -o = %d, want 1", code)
+	}
+}
+
+func TestRun_OutputIsInput(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("INFO: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.log")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	for _, out := range []string{dir + "/./app.log", link} {
+		var stdout, stderr bytes.Buffer
+		if code := Run([]string{"-force", "-o", out, path}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "would overwrite its input") {
+			t.Errorf("Run(-o %s) = %d, stderr %q", out, code, stderr.String())
+		}
+	}
+	if got, _ := os.ReadFile(path); string(got) != "INFO: a\n" {
+		t.Errorf("input = %q", got)
+	}
+}
+
+func TestRun_FlagErrorPrintedOnce(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	for _, args := range [][]string{{"-unknown", "app.log"}, {"verify", "-unknown"}} {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("Run(%q) = %d, want 1", args, code)
+		}
+		if n := strings.Count(stderr.String(), "-unknown"); n != 1 {
+			t.Errorf("Run(%q) reported the flag %d times: %q", args, n, stderr.String())
+		}
+	}
+}
+
+func TestRun_FollowOutputIsInput(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("INFO: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An existing output is fine to append to, but never the followed file
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-f", "-output-dir", dir, "-template", "{dir}/{name}{ext}", path}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "would overwrite its input") {
+		t.Errorf("Run() = %d, stderr %q", code, stderr.String())
+	}
+	if got, _ := os.ReadFile(path); string(got) != "INFO: a\n" {
+		t.Errorf("input = %q", got)
+	}
+}
+
+func TestRun_BatchOverlappingArguments(t *testing.T) {
+	setupFilters(t, `[]`)
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("INFO: "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := []string{"-force", "-template", "{dir}/{name}.clean{ext}", filepath.Join(dir, "*.log"), filepath.Join(dir, "a.log")}
+
+	// The second run must neither clean a.log twice nor the outputs of the first
+	for run := 0; run < 2; run++ {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("Run() = %d, stderr %q", code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "Total: 2 lines read") {
+			t.Errorf("run %d: stderr = %q, want each input once", run, stderr.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.clean.clean.log")); !os.IsNotExist(err) {
+		t.Errorf("an output was cleaned again: %v", err)
+	}
+}
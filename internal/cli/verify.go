@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sstreichan/logcleaner/internal/manifest"
+)
+
+// runVerify re-runs manifests, given directly or by the output they
+// describe, and reports whether each output is reproduced exactly
+func runVerify(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("logcleaner verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: logcleaner verify <manifest|output>...")
+		fmt.Fprintln(stderr, "Re-runs each manifest and confirms that its output is identical.")
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no manifest given")
+	}
+
+	failed := 0
+	for _, arg := range fs.Args() {
+		path := arg
+		if !strings.HasSuffix(path, manifest.Suffix) {
+			path = manifest.Path(path)
+		}
+
+		m, err := manifest.Load(path)
+		if err == nil {
+			err = manifest.Verify(m)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "FAILED %s: %s\n", path, strings.ReplaceAll(err.Error(), "\n", "; "))
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "OK %s (%d inputs, %d filters, sha256 %s)\n", m.Output.Path, len(m.Inputs), len(m.Filters), m.Output.SHA256)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d manifests failed verification", failed, fs.NArg())
+	}
+	return nil
+}
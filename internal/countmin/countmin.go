@@ -0,0 +1,96 @@
+// Package countmin implements a count-min sketch: a fixed-size table of
+// counters that estimates how often a key was added. Estimates are never
+// too low; they can be too high when keys collide in every row.
+package countmin
+
+import "math"
+
+// maxDepth bounds the rows so Count can index them without allocating
+const maxDepth = 8
+
+// Sketch counts keys in depth rows of width counters each
+type Sketch struct {
+	rows [][]uint32
+	mask uint64
+	idx  []uint64
+}
+
+// New returns a sketch with width rounded up to a power of two and depth
+// between 1 and 8. It uses 4*width*depth bytes.
+func New(width, depth int) *Sketch {
+	depth = max(1, min(depth, maxDepth))
+	size := 1
+	for size < width {
+		size <<= 1
+	}
+
+	rows := make([][]uint32, depth)
+	for i := range rows {
+		rows[i] = make([]uint32, size)
+	}
+	return &Sketch{rows: rows, mask: uint64(size - 1), idx: make([]uint64, depth)}
+}
+
+// Add counts key once more and returns its new estimate. Only the counters
+// below the new estimate are raised (conservative update), which keeps
+// estimates of rare keys close to their true count.
+//
+// Add is not safe for concurrent use; Count is, without concurrent Adds.
+func (s *Sketch) Add(key []byte) uint32 {
+	s.index(key, s.idx)
+
+	est := uint32(math.MaxUint32)
+	for i, row := range s.rows {
+		est = min(est, row[s.idx[i]])
+	}
+	if est == math.MaxUint32 {
+		return est
+	}
+	est++
+
+	for i, row := range s.rows {
+		if row[s.idx[i]] < est {
+			row[s.idx[i]] = est
+		}
+	}
+	return est
+}
+
+// Count returns the estimated number of times key was added
+func (s *Sketch) Count(key []byte) uint32 {
+	var idx [maxDepth]uint64
+	indexes := idx[:len(s.rows)]
+	s.index(key, indexes)
+
+	est := uint32(math.MaxUint32)
+	for i, row := range s.rows {
+		est = min(est, row[indexes[i]])
+	}
+	return est
+}
+
+// index derives one counter per row from a single 64-bit hash
+func (s *Sketch) index(key []byte, idx []uint64) {
+	h := hash(key)
+	h1, h2 := h&math.MaxUint32, (h>>32)|1
+	for i := range idx {
+		idx[i] = (h1 + uint64(i)*h2) & s.mask
+	}
+}
+
+// hash is 64-bit FNV-1a with a final avalanche. It is fixed rather than
+// seeded per process, so that the same input collides the same way and a
+// frequency filter drops the same lines in every run.
+func hash(key []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, b := range key {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
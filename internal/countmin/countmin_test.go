@@ -0,0 +1,67 @@
+package countmin
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSketch(t *testing.T) {
+	s := New(1000, 4)
+
+	for i := 0; i < 500; i++ {
+		s.Add([]byte("frequent"))
+	}
+	for i := 0; i < 3; i++ {
+		s.Add([]byte("rare"))
+	}
+	for i := 0; i < 2000; i++ {
+		s.Add([]byte(fmt.Sprintf("unique %d", i)))
+	}
+
+	if got := s.Count([]byte("frequent")); got < 500 {
+		t.Errorf("Count(frequent) = %d, want at least 500", got)
+	}
+	if got := s.Count([]byte("rare")); got < 3 || got > 10 {
+		t.Errorf("Count(rare) = %d, want close to 3", got)
+	}
+	if got := s.Count([]byte("never added")); got > 10 {
+		t.Errorf("Count(never added) = %d, want close to 0", got)
+	}
+}
+
+func TestSketch_ConcurrentCount(t *testing.T) {
+	s := New(64, 3)
+	if got := s.Add([]byte("a")); got != 1 {
+		t.Errorf("Add() = %d, want 1", got)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := s.Count([]byte("a")); got != 1 {
+				t.Errorf("Count() = %d, want 1", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSketch_Deterministic(t *testing.T) {
+	// A narrow sketch collides often; it must collide the same way each time
+	a, b := New(16, 2), New(16, 2)
+	for i := 0; i < 200; i++ {
+		key := []byte(fmt.Sprintf("key %d", i%50))
+		a.Add(key)
+		b.Add(key)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key %d", i))
+		if ca, cb := a.Count(key), b.Count(key); ca != cb {
+			t.Errorf("Count(%s) = %d and %d", key, ca, cb)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package discover
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// CleanedSuffix is appended to an input path to name its cleaned output
+	CleanedSuffix = ".cleaned"
+	// BackupSuffix names the backup of a file cleaned in place
+	BackupSuffix = ".bak"
+	// ManifestSuffix is appended to an output path to name its manifest
+	ManifestSuffix = ".manifest.json"
+)
+
+// IsPattern reports whether path contains glob meta characters
+func IsPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ExpandPath expands environment variables ($VAR or ${VAR}) and a leading
+// ~ or ~user in path, as a shell would before globbing. Unset variables and
+// unknown users are left in place and reported in the error.
+func ExpandPath(path string) (string, error) {
+	var errs []error
+	if strings.Contains(path, "$") {
+		path = os.Expand(path, func(name string) string {
+			if value, ok := os.LookupEnv(name); ok {
+				return value
+			}
+			errs = append(errs, fmt.Errorf("environment variable %s is not set", name))
+			return "$" + name
+		})
+	}
+
+	if strings.HasPrefix(path, "~") {
+		name := path[1:]
+		if i := strings.IndexAny(name, "/"+string(filepath.Separator)); i >= 0 {
+			name = name[:i]
+		}
+		home, err := homeDir(name)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			path = home + path[1+len(name):]
+		}
+	}
+
+	return path, errors.Join(errs...)
+}
+
+// homeDir returns the home directory of the named user, or of the current
+// user if name is empty
+func homeDir(name string) (string, error) {
+	if name == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return home, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("unknown user %s", name)
+	}
+	return u.HomeDir, nil
+}
+
+// Expand resolves a file, a directory or a glob pattern to the sorted list
+// of regular files it names. A directory yields the files directly inside
+// it, skipping hidden files. Cleaned outputs, backups and manifests are
+// skipped in both cases.
+func Expand(path string) ([]string, error) {
+	var candidates []string
+
+	if IsPattern(path) {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		candidates = matches
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), ".") {
+				candidates = append(candidates, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	var files []string
+	for _, candidate := range candidates {
+		if strings.HasSuffix(candidate, CleanedSuffix) || strings.HasSuffix(candidate, BackupSuffix) ||
+			strings.HasSuffix(candidate, ManifestSuffix) {
+			continue
+		}
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			files = append(files, candidate)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", path)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Unique drops the paths that name a file already listed, also through
+// symlinks, hard links or differently written paths, keeping the order
+func Unique(paths []string) []string {
+	type identity struct {
+		size    int64
+		modTime int64
+	}
+	seen := make(map[string]bool, len(paths))
+	infos := make(map[identity][]os.FileInfo)
+	var unique []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+
+		// Only files of equal size and mtime can be the same
+		if info, err := os.Stat(path); err == nil {
+			id := identity{info.Size(), info.ModTime().UnixNano()}
+			if slices.ContainsFunc(infos[id], func(other os.FileInfo) bool { return os.SameFile(info, other) }) {
+				continue
+			}
+			infos[id] = append(infos[id], info)
+		}
+		unique = append(unique, path)
+	}
+	return unique
+}
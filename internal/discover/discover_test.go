@@ -0,0 +1,111 @@
+package discover
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.log", "a.log", "a.log.1", "a.log.cleaned", "a.log.bak", "a.log.cleaned.manifest.json", ".hidden", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.log"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{"file", filepath.Join(dir, "b.log"), join("b.log"), false},
+		{"directory", dir, join("a.log", "a.log.1", "b.log", "notes.txt"), false},
+		{"glob", filepath.Join(dir, "*.log*"), join("a.log", "a.log.1", "b.log"), false},
+		{"no match", filepath.Join(dir, "*.gz"), nil, true},
+		{"missing", filepath.Join(dir, "missing.log"), nil, true},
+		{"bad pattern", filepath.Join(dir, "[.log"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnique(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(dir, "link.log")
+	if err := os.Symlink(b, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	got := Unique([]string{a, b, filepath.Join(dir, ".", "a.log"), link, a})
+	if want := []string{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unique() = %v, want %v", got, want)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("LOG_ROOT", "/srv/logs")
+	current, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"/var/log/app.log", "/var/log/app.log", ""},
+		{"$LOG_ROOT/api/*.log", "/srv/logs/api/*.log", ""},
+		{"${LOG_ROOT}/api/", "/srv/logs/api/", ""},
+		{"~", home, ""},
+		{"~/logs", home + "/logs", ""},
+		{"~" + current.Username + "/logs", current.HomeDir + "/logs", ""},
+		{"$NO_SUCH_LOG_VAR/app.log", "$NO_SUCH_LOG_VAR/app.log", "NO_SUCH_LOG_VAR is not set"},
+		{"~no-such-log-user/logs", "~no-such-log-user/logs", "unknown user no-such-log-user"},
+	}
+
+	for _, tt := range tests {
+		got, err := ExpandPath(tt.path)
+		if got != tt.want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("ExpandPath(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package drain groups log lines into templates with the Drain algorithm:
+// lines are routed through a fixed-depth prefix tree by token count and
+// leading tokens, and joined to the most similar template in the leaf
+// they reach, replacing the tokens that differ with a wildcard.
+package drain
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Wildcard stands for any single token in a template
+const Wildcard = "<*>"
+
+const (
+	defaultDepth       = 4
+	defaultSimilarity  = 0.4
+	defaultMaxChildren = 100
+	defaultMaxClusters = 1000
+)
+
+// Options tune a Miner
+type Options struct {
+	// Depth of the prefix tree including the root, the token count level
+	// and the leaf level; lines are routed by their first Depth-3 tokens.
+	// It defaults to 4.
+	Depth int
+	// Similarity is the share of tokens a line must have in common with a
+	// template to join it; it defaults to 0.4
+	Similarity float64
+	// MaxChildren bounds the children of a tree node; further tokens are
+	// routed through a wildcard child. It defaults to 100.
+	MaxChildren int
+	// MaxClusters bounds the number of templates; lines matching none once
+	// it is reached are counted as Unclustered. It defaults to 1000.
+	MaxClusters int
+}
+
+// Template is a line shape with Wildcard for its variable tokens
+type Template struct {
+	Tokens []string
+	Count  int
+}
+
+// String returns the tokens separated by single spaces
+func (t Template) String() string {
+	return strings.Join(t.Tokens, " ")
+}
+
+// Pattern returns a regular expression matching the lines of the template:
+// literal tokens are escaped, wildcards match one token and tokens are
+// separated by any whitespace
+func (t Template) Pattern() string {
+	parts := make([]string, len(t.Tokens))
+	for i, token := range t.Tokens {
+		if token == Wildcard {
+			parts[i] = `\S+`
+		} else {
+			parts[i] = regexp.QuoteMeta(token)
+		}
+	}
+	return `^\s*` + strings.Join(parts, `\s+`) + `\s*$`
+}
+
+// Miner clusters lines into templates
+type Miner struct {
+	opts     Options
+	root     map[int]*node
+	clusters []*cluster
+	tokens   [][]byte
+
+	// Unclustered counts lines dropped after MaxClusters was reached
+	Unclustered int
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*cluster
+}
+
+type cluster struct {
+	tokens []string
+	count  int
+}
+
+// New returns a Miner; zero options take their defaults
+func New(opts Options) *Miner {
+	if opts.Depth < 3 {
+		opts.Depth = defaultDepth
+	}
+	if opts.Similarity <= 0 {
+		opts.Similarity = defaultSimilarity
+	}
+	if opts.MaxChildren <= 0 {
+		opts.MaxChildren = defaultMaxChildren
+	}
+	if opts.MaxClusters <= 0 {
+		opts.MaxClusters = defaultMaxClusters
+	}
+	return &Miner{opts: opts, root: make(map[int]*node)}
+}
+
+// Add adds a line to the template it is most similar to, or starts a new
+// one. Tokens are separated by whitespace; tokens containing a digit are
+// taken for variables from the start. Blank lines are ignored.
+func (m *Miner) Add(line []byte) {
+	m.tokens = m.tokens[:0]
+	for _, token := range bytes.Fields(line) {
+		if hasDigit(token) {
+			token = []byte(Wildcard)
+		}
+		m.tokens = append(m.tokens, token)
+	}
+	if len(m.tokens) == 0 {
+		return
+	}
+
+	leaf := m.leaf(m.tokens)
+	if c := m.match(leaf, m.tokens); c != nil {
+		for i, token := range m.tokens {
+			if c.tokens[i] != string(token) {
+				c.tokens[i] = Wildcard
+			}
+		}
+		c.count++
+		return
+	}
+
+	if len(m.clusters) == m.opts.MaxClusters {
+		m.Unclustered++
+		return
+	}
+	c := &cluster{tokens: make([]string, len(m.tokens)), count: 1}
+	for i, token := range m.tokens {
+		c.tokens[i] = string(token)
+	}
+	leaf.clusters = append(leaf.clusters, c)
+	m.clusters = append(m.clusters, c)
+}
+
+// Top returns up to n templates with the most lines, most frequent first
+func (m *Miner) Top(n int) []Template {
+	sorted := append([]*cluster(nil), m.clusters...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].count > sorted[j].count
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	templates := make([]Template, len(sorted))
+	for i, c := range sorted {
+		templates[i] = Template{Tokens: append([]string(nil), c.tokens...), Count: c.count}
+	}
+	return templates
+}
+
+// leaf walks the prefix tree for tokens, creating missing nodes
+func (m *Miner) leaf(tokens [][]byte) *node {
+	n := m.root[len(tokens)]
+	if n == nil {
+		n = &node{}
+		m.root[len(tokens)] = n
+	}
+
+	for i := 0; i < m.opts.Depth-3 && i < len(tokens); i++ {
+		key := string(tokens[i])
+		next := n.children[key]
+		if next == nil {
+			if n.children == nil {
+				n.children = make(map[string]*node)
+			}
+			if len(n.children) >= m.opts.MaxChildren {
+				key = Wildcard
+				next = n.children[key]
+			}
+			if next == nil {
+				next = &node{}
+				n.children[key] = next
+			}
+		}
+		n = next
+	}
+	return n
+}
+
+// match returns the most similar template in leaf if it is similar enough,
+// preferring the one with more wildcards on a tie
+func (m *Miner) match(leaf *node, tokens [][]byte) *cluster {
+	var best *cluster
+	bestSim, bestParams := -1.0, -1
+	for _, c := range leaf.clusters {
+		same, params := 0, 0
+		for i, token := range c.tokens {
+			switch {
+			case token == string(tokens[i]):
+				same++
+			case token == Wildcard:
+				params++
+			}
+		}
+		sim := float64(same) / float64(len(tokens))
+		if sim > bestSim || (sim == bestSim && params > bestParams) {
+			best, bestSim, bestParams = c, sim, params
+		}
+	}
+	if bestSim < m.opts.Similarity {
+		return nil
+	}
+	return best
+}
+
+func hasDigit(s []byte) bool {
+	for _, b := range s {
+		if b >= '0' && b <= '9' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package drain
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMiner(t *testing.T) {
+	lines := []string{
+		"connected to db-01 in 12ms",
+		"user alice logged in",
+		"connected to db-02 in 7ms",
+		"user bob logged in",
+		"connected to cache in 1ms",
+		"disk full",
+		"",
+	}
+
+	m := New(Options{})
+	for _, line := range lines {
+		m.Add([]byte(line))
+	}
+
+	got := m.Top(10)
+	want := []Template{
+		{Tokens: []string{"connected", "to", "<*>", "in", "<*>"}, Count: 3},
+		{Tokens: []string{"user", "<*>", "logged", "in"}, Count: 2},
+		{Tokens: []string{"disk", "full"}, Count: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Top() = %v, want %v", got, want)
+	}
+
+	if top := m.Top(1); len(top) != 1 || top[0].Count != 3 {
+		t.Errorf("Top(1) = %v", top)
+	}
+}
+
+func TestMiner_MaxClusters(t *testing.T) {
+	m := New(Options{MaxClusters: 1})
+	m.Add([]byte("first shape"))
+	m.Add([]byte("something entirely different here"))
+
+	if len(m.Top(10)) != 1 || m.Unclustered != 1 {
+		t.Errorf("Top() = %v, Unclustered = %d", m.Top(10), m.Unclustered)
+	}
+}
+
+func TestTemplate_Pattern(t *testing.T) {
+	tmpl := Template{Tokens: []string{"GET", "/api/v1.0?x=(1)", "<*>", "[done]"}}
+
+	re, err := regexp.Compile(tmpl.Pattern())
+	if err != nil {
+		t.Fatalf("Pattern() = %q does not compile: %v", tmpl.Pattern(), err)
+	}
+
+	tests := map[string]bool{
+		"GET /api/v1.0?x=(1) 200 [done]":       true,
+		"  GET\t/api/v1.0?x=(1)  404 [done]  ": true,
+		"GET /api/v1x0?x=(1) 200 [done]":       false,
+		"GET /api/v1.0?x=(1) 200 ms [done]":    false,
+		"POST GET /api/v1.0?x=(1) 200 [done]":  false,
+	}
+	for line, want := range tests {
+		if got := re.MatchString(line); got != want {
+			t.Errorf("%q matches %q = %v, want %v", tmpl.Pattern(), line, got, want)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package filter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 )
 
 type FilterType string
@@ -11,16 +14,82 @@ type FilterType string
 const (
 	TypeRemove FilterType = "remove"
 	TypeKeep   FilterType = "keep"
+	// TypeFrequency removes the lines matching the pattern whose shape
+	// occurs more than Threshold times; an empty pattern matches every line
+	TypeFrequency FilterType = "frequency"
+	// TypeSample keeps a subset of the lines matching the pattern: one in
+	// Rate, or with Limit set at most Limit lines of each shape per
+	// Interval. An empty pattern matches every line.
+	TypeSample FilterType = "sample"
 )
 
+// Duration is a time.Duration stored as text such as "1m30s" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Options control how a filter's pattern is matched against a line
+type Options struct {
+	IgnoreCase bool `json:"ignore_case,omitempty"`
+	WholeWord  bool `json:"whole_word,omitempty"`
+	Literal    bool `json:"literal,omitempty"`
+	Invert     bool `json:"invert,omitempty"`
+}
+
+// String returns a short, human readable list of the enabled options
+func (o Options) String() string {
+	var parts []string
+	if o.IgnoreCase {
+		parts = append(parts, "ignore case")
+	}
+	if o.WholeWord {
+		parts = append(parts, "whole word")
+	}
+	if o.Literal {
+		parts = append(parts, "literal")
+	}
+	if o.Invert {
+		parts = append(parts, "invert")
+	}
+	return strings.Join(parts, ", ")
+}
+
 type Filter struct {
 	Name    string     `json:"name"`
 	Pattern string     `json:"pattern"`
 	Type    FilterType `json:"type"`
-	regex   *regexp.Regexp
+	Options
+	// Threshold is how often a shape may occur for frequency filters
+	Threshold int `json:"threshold,omitempty"`
+	// Rate, or Limit per Interval, is the share a sample filter keeps
+	Rate     int      `json:"rate,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Interval Duration `json:"interval,omitempty"`
+	regex    *regexp.Regexp
+
+	// Literal filters without word boundaries skip the regex engine
+	substring      string
+	substringBytes []byte
+	fold           bool
+	compiled       bool
 }
 
 func New(name, pattern string, filterType FilterType) (*Filter, error) {
+	return NewWithOptions(name, pattern, filterType, Options{})
+}
+
+func NewWithOptions(name, pattern string, filterType FilterType, opts Options) (*Filter, error) {
 	if name == "" {
 		return nil, fmt.Errorf("filter name cannot be empty")
 	}
@@ -28,28 +97,202 @@ func New(name, pattern string, filterType FilterType) (*Filter, error) {
 		return nil, fmt.Errorf("filter pattern cannot be empty")
 	}
 
-	regex, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("invalid regex pattern: %w", err)
-	}
-
 	if filterType != TypeRemove && filterType != TypeKeep {
 		return nil, fmt.Errorf("invalid filter type: must be 'remove' or 'keep'")
 	}
 
-	return &Filter{
+	f := &Filter{
 		Name:    name,
 		Pattern: pattern,
 		Type:    filterType,
-		regex:   regex,
-	}, nil
+		Options: opts,
+	}
+	if err := f.compile(); err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	return f, nil
+}
+
+// NewFrequency returns a filter removing the lines matching pattern whose
+// shape occurs more than threshold times
+func NewFrequency(name, pattern string, threshold int, opts Options) (*Filter, error) {
+	if name == "" {
+		return nil, fmt.Errorf("filter name cannot be empty")
+	}
+	if threshold < 1 {
+		return nil, fmt.Errorf("frequency threshold must be at least 1")
+	}
+
+	f := &Filter{
+		Name:      name,
+		Pattern:   pattern,
+		Type:      TypeFrequency,
+		Options:   opts,
+		Threshold: threshold,
+	}
+	if err := f.compile(); err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	return f, nil
+}
+
+// NewSample returns a filter keeping one in rate of the lines matching
+// pattern
+func NewSample(name, pattern string, rate int, opts Options) (*Filter, error) {
+	if rate < 2 {
+		return nil, fmt.Errorf("sample rate must be at least 2")
+	}
+	return newSubset(&Filter{Name: name, Pattern: pattern, Type: TypeSample, Options: opts, Rate: rate})
+}
+
+// NewRateLimit returns a filter keeping at most limit lines of each shape
+// matching pattern per interval
+func NewRateLimit(name, pattern string, limit int, interval time.Duration, opts Options) (*Filter, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("rate limit must be at least 1")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("rate limit interval must be positive")
+	}
+	return newSubset(&Filter{Name: name, Pattern: pattern, Type: TypeSample, Options: opts, Limit: limit, Interval: Duration(interval)})
+}
+
+func newSubset(f *Filter) (*Filter, error) {
+	if f.Name == "" {
+		return nil, fmt.Errorf("filter name cannot be empty")
+	}
+	if err := f.compile(); err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	return f, nil
 }
 
+// RateLimited reports whether a sample filter limits lines per interval
+// rather than keeping a fixed share
+func (f *Filter) RateLimited() bool {
+	return f.Limit > 0
+}
+
+// Selects reports whether a frequency or sample filter considers line,
+// which is every line for an empty pattern
+func (f *Filter) Selects(line []byte) bool {
+	return f.Pattern == "" || f.MatchesBytes(line)
+}
+
+// Expression returns the regular expression the filter effectively uses,
+// with literal escaping, word boundaries and case folding applied
+func (f *Filter) Expression() string {
+	expr := f.Pattern
+	if f.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if f.WholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if f.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	return expr
+}
+
+func (f *Filter) compile() error {
+	f.regex, f.substring, f.substringBytes, f.fold = nil, "", nil, false
+	f.compiled = true
+
+	if f.Literal && !f.WholeWord {
+		if !f.IgnoreCase {
+			f.substring = f.Pattern
+			f.substringBytes = []byte(f.Pattern)
+			return nil
+		}
+		if isASCII(f.Pattern) {
+			f.substring = strings.ToLower(f.Pattern)
+			f.fold = true
+			return nil
+		}
+	}
+
+	regex, err := regexp.Compile(f.Expression())
+	if err != nil {
+		return err
+	}
+	f.regex = regex
+	return nil
+}
+
+// Matches reports whether the filter applies to line, taking Invert into account
 func (f *Filter) Matches(line string) bool {
-	if f.regex == nil {
-		f.regex, _ = regexp.Compile(f.Pattern)
+	return f.matchesPattern(line) != f.Invert
+}
+
+func (f *Filter) matchesPattern(line string) bool {
+	if !f.compiled {
+		f.compile()
+	}
+	switch {
+	case f.substring != "" && f.fold:
+		return indexFoldASCII(line, f.substring) >= 0
+	case f.substring != "":
+		return strings.Contains(line, f.substring)
+	case f.regex != nil:
+		return f.regex.MatchString(line)
+	}
+	return false
+}
+
+// MatchesBytes is like Matches but avoids converting line to a string
+func (f *Filter) MatchesBytes(line []byte) bool {
+	return f.matchesPatternBytes(line) != f.Invert
+}
+
+func (f *Filter) matchesPatternBytes(line []byte) bool {
+	if !f.compiled {
+		f.compile()
 	}
-	return f.regex.MatchString(line)
+	switch {
+	case f.substring != "" && f.fold:
+		return indexFoldASCII(line, f.substring) >= 0
+	case f.substring != "":
+		return bytes.Contains(line, f.substringBytes)
+	case f.regex != nil:
+		return f.regex.Match(line)
+	}
+	return false
+}
+
+// FindAllIndex returns the byte ranges of all pattern occurrences in line.
+// Invert is ignored, so inverted filters report the text they reject.
+func (f *Filter) FindAllIndex(line string) [][]int {
+	if !f.compiled {
+		f.compile()
+	}
+	if f.regex != nil {
+		return f.regex.FindAllStringIndex(line, -1)
+	}
+	if f.substring == "" {
+		return nil
+	}
+
+	var spans [][]int
+	pos := 0
+	for pos <= len(line) {
+		var idx int
+		if f.fold {
+			idx = indexFoldASCII(line[pos:], f.substring)
+		} else {
+			idx = strings.Index(line[pos:], f.substring)
+		}
+		if idx < 0 {
+			break
+		}
+		start := pos + idx
+		end := start + len(f.substring)
+		spans = append(spans, []int{start, end})
+		pos = end
+	}
+	return spans
 }
 
 func (f *Filter) MarshalJSON() ([]byte, error) {
@@ -64,10 +307,55 @@ func (f *Filter) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	regex, err := regexp.Compile(f.Pattern)
-	if err != nil {
+	if err := f.compile(); err != nil {
 		return fmt.Errorf("invalid regex in stored filter: %w", err)
 	}
-	f.regex = regex
 	return nil
 }
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// indexFoldASCII returns the index of the lower-case ASCII needle in s,
+// comparing case-insensitively, or -1 if it is not present
+func indexFoldASCII[T string | []byte](s T, needle string) int {
+	n := len(needle)
+	if n == 0 {
+		return 0
+	}
+
+	first := needle[0]
+	firstUpper := first
+	if 'a' <= first && first <= 'z' {
+		firstUpper -= 'a' - 'A'
+	}
+
+	for i := 0; i+n <= len(s); i++ {
+		if c := s[i]; c != first && c != firstUpper {
+			continue
+		}
+		if equalFoldASCII(s[i+1:i+n], needle[1:]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equalFoldASCII[T string | []byte](s T, lower string) bool {
+	for i := 0; i < len(lower); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != lower[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,7 +1,10 @@
 package filter
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -41,3 +44,156 @@ func TestFilterMatches(t *testing.T) {
 		t.Error("Expected no match for INFO line")
 	}
 }
+
+func TestFilterOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    Options
+		line    string
+		want    bool
+	}{
+		{"ignore case", "^error", Options{IgnoreCase: true}, "ERROR: boom", true},
+		{"case sensitive", "^error", Options{}, "ERROR: boom", false},
+		{"whole word", "err", Options{WholeWord: true}, "stderr output", false},
+		{"whole word match", "err", Options{WholeWord: true}, "err: output", true},
+		{"literal", "a.b[1]", Options{Literal: true}, "value a.b[1] set", true},
+		{"literal no regex", "a.b", Options{Literal: true}, "axb", false},
+		{"literal ignore case", "Timeout", Options{Literal: true, IgnoreCase: true}, "TIMEOUT reached", true},
+		{"literal unicode ignore case", "Größe", Options{Literal: true, IgnoreCase: true}, "GRÖSSE größe", true},
+		{"literal whole word", "id", Options{Literal: true, WholeWord: true}, "user_id=1", false},
+		{"invert", "^INFO", Options{Invert: true}, "ERROR: boom", true},
+		{"invert match", "^INFO", Options{Invert: true}, "INFO: ok", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewWithOptions("test", tt.pattern, TypeRemove, tt.opts)
+			if err != nil {
+				t.Fatalf("NewWithOptions() error = %v", err)
+			}
+			if got := f.Matches(tt.line); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+			if got := f.MatchesBytes([]byte(tt.line)); got != tt.want {
+				t.Errorf("MatchesBytes(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAllIndex_Literal(t *testing.T) {
+	f, _ := NewWithOptions("test", "ab", TypeRemove, Options{Literal: true, IgnoreCase: true})
+
+	spans := f.FindAllIndex("xAByab")
+	if len(spans) != 2 || spans[0][0] != 1 || spans[1][0] != 4 {
+		t.Errorf("Unexpected spans: %v", spans)
+	}
+}
+
+func TestOptionsJSONRoundTrip(t *testing.T) {
+	f, _ := NewWithOptions("test", "a.b", TypeKeep, Options{Literal: true, Invert: true})
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded Filter
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Options != f.Options {
+		t.Errorf("Expected options %+v, got %+v", f.Options, loaded.Options)
+	}
+	if loaded.Matches("a.b") || !loaded.Matches("axb") {
+		t.Error("Loaded filter should match literally and inverted")
+	}
+}
+
+func TestNewFrequency(t *testing.T) {
+	if _, err := NewFrequency("noise", "", 0, Options{}); err == nil {
+		t.Error("Expected an error for a threshold below 1")
+	}
+	if _, err := NewFrequency("", "", 10, Options{}); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+
+	all, err := NewFrequency("noise", "", 10, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !all.Selects([]byte("anything")) {
+		t.Error("A frequency filter without pattern should select every line")
+	}
+
+	info, _ := NewFrequency("info noise", "^INFO", 10, Options{})
+	if !info.Selects([]byte("INFO: x")) || info.Selects([]byte("ERROR: x")) {
+		t.Error("A frequency filter should select only lines matching its pattern")
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Filter
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Type != TypeFrequency || loaded.Threshold != 10 {
+		t.Errorf("Loaded filter = %+v, want the frequency type and threshold", loaded)
+	}
+}
+
+func TestNewSample(t *testing.T) {
+	if _, err := NewSample("debug", "", 1, Options{}); err == nil {
+		t.Error("Expected an error for a rate below 2")
+	}
+	if _, err := NewRateLimit("limit", "", 5, 0, Options{}); err == nil {
+		t.Error("Expected an error for an interval that is not positive")
+	}
+
+	limit, err := NewRateLimit("limit", "^GET", 5, 90*time.Second, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limit.RateLimited() {
+		t.Error("A filter with a limit should be rate limited")
+	}
+
+	data, err := json.Marshal(limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"interval":"1m30s"`) {
+		t.Errorf("JSON = %s, want the interval as text", data)
+	}
+	var loaded Filter
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Type != TypeSample || loaded.Limit != 5 || time.Duration(loaded.Interval) != 90*time.Second {
+		t.Errorf("Loaded filter = %+v, want the sample type, limit and interval", loaded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"name":"x","pattern":"","type":"sample","limit":1,"interval":"soon"}`), &loaded); err == nil {
+		t.Error("Expected an error for an invalid interval")
+	}
+}
+
+func TestUnmarshalJSON_Recompiles(t *testing.T) {
+	var f Filter
+	if err := json.Unmarshal([]byte(`{"name":"a","pattern":"boom","type":"remove","literal":true}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"name":"a","pattern":"^INFO","type":"remove","literal":false}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.substring != "" || f.substringBytes != nil || f.regex == nil {
+		t.Errorf("stale literal state: substring %q, bytes %q", f.substring, f.substringBytes)
+	}
+	if !f.MatchesBytes([]byte("INFO: ok")) || f.MatchesBytes([]byte("boom")) {
+		t.Error("MatchesBytes() used the previous pattern")
+	}
+}
@@ -0,0 +1,91 @@
+package filter
+
+import "regexp/syntax"
+
+// maxLiterals caps how many alternatives a filter may contribute to a prefilter
+const maxLiterals = 32
+
+// Literals returns strings of which at least one occurs in every line the
+// pattern matches, ignoring Invert. It returns nil when no such set can be
+// derived, e.g. for case-insensitive patterns or leading wildcards.
+func (f *Filter) Literals() []string {
+	if !f.compiled {
+		f.compile()
+	}
+	if f.substring != "" {
+		if f.fold {
+			return nil
+		}
+		return []string{f.substring}
+	}
+	if f.regex == nil {
+		return nil
+	}
+
+	re, err := syntax.Parse(f.Expression(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return requiredLiterals(re.Simplify())
+}
+
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 || len(re.Rune) == 0 {
+			return nil
+		}
+		return []string{string(re.Rune)}
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min < 1 {
+			return nil
+		}
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpConcat:
+		// Every part of a concatenation must match, so any part with
+		// literals will do; prefer the one with the longest shortest literal
+		var best []string
+		bestLen := 0
+		for _, sub := range re.Sub {
+			lits := requiredLiterals(sub)
+			if lits == nil {
+				continue
+			}
+			if n := shortest(lits); n > bestLen {
+				best, bestLen = lits, n
+			}
+		}
+		return best
+
+	case syntax.OpAlternate:
+		var all []string
+		for _, sub := range re.Sub {
+			lits := requiredLiterals(sub)
+			if lits == nil {
+				return nil
+			}
+			all = append(all, lits...)
+			if len(all) > maxLiterals {
+				return nil
+			}
+		}
+		return all
+	}
+
+	return nil
+}
+
+func shortest(lits []string) int {
+	n := len(lits[0])
+	for _, lit := range lits[1:] {
+		if len(lit) < n {
+			n = len(lit)
+		}
+	}
+	return n
+}
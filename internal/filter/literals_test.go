@@ -0,0 +1,36 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		opts    Options
+		want    []string
+	}{
+		{"^ERROR", Options{}, []string{"ERROR"}},
+		{"^ERROR|^FATAL", Options{}, []string{"ERROR", "FATAL"}},
+		{`HTTP/\d\.\d" [45]\d{2}`, Options{}, []string{"HTTP/"}},
+		{`user=(alice|bob) logged in`, Options{}, []string{" logged in"}},
+		{"a.b", Options{Literal: true}, []string{"a.b"}},
+		{"^error", Options{IgnoreCase: true}, nil},
+		{`\d+`, Options{}, nil},
+		{"foo|.*", Options{}, nil},
+		{"id", Options{WholeWord: true}, []string{"id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			f, err := NewWithOptions("test", tt.pattern, TypeRemove, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Literals(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Literals() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
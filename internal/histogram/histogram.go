@@ -0,0 +1,225 @@
+// Package histogram counts log lines per time bucket and severity level,
+// in total and kept by the filters, to show where a run removed lines.
+package histogram
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/logtime"
+	"github.com/sstreichan/logcleaner/internal/severity"
+)
+
+// widths are the bucket widths a Histogram coarsens through; each divides
+// the next, so buckets merge without being split
+var widths = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 30 * time.Minute,
+	time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// maxBuckets is the number of buckets above which the width grows
+const maxBuckets = 1024
+
+// Count is a number of lines and how many of them were kept
+type Count struct {
+	Total int
+	Kept  int
+}
+
+// Removed returns the lines that were not kept
+func (c Count) Removed() int {
+	return c.Total - c.Kept
+}
+
+func (c *Count) add(o Count) {
+	c.Total += o.Total
+	c.Kept += o.Kept
+}
+
+// Histogram counts lines by the timestamp found near their start; lines
+// without one, such as stack trace lines, count towards the last
+// timestamp before them. A Histogram is not safe for concurrent use.
+type Histogram struct {
+	// Levels counts lines per detected severity level
+	Levels [severity.NumLevels]Count
+
+	width   int // index into widths
+	buckets map[int64]*Count
+	leading Count // lines before the first timestamp
+	last    int64 // unix seconds of the last timestamp
+	timed   bool
+	parser  logtime.Parser
+}
+
+// New returns an empty Histogram
+func New() *Histogram {
+	return &Histogram{buckets: make(map[int64]*Count)}
+}
+
+// Add counts a line
+func (h *Histogram) Add(line []byte, kept bool) {
+	c := Count{Total: 1}
+	if kept {
+		c.Kept = 1
+	}
+	h.Levels[severity.Detect(line)].add(c)
+
+	if t, ok := h.parser.Parse(line); ok {
+		h.last, h.timed = t.Unix(), true
+	}
+	if !h.timed {
+		h.leading.add(c)
+		return
+	}
+	h.addAt(h.last, c)
+}
+
+// Merge adds the counts of o, which covers the lines following those
+// counted by h
+func (h *Histogram) Merge(o *Histogram) {
+	for i := range o.Levels {
+		h.Levels[i].add(o.Levels[i])
+	}
+
+	if h.timed {
+		h.addAt(h.last, o.leading)
+	} else {
+		h.leading.add(o.leading)
+	}
+	for h.width < o.width {
+		h.grow()
+	}
+	seconds := o.seconds()
+	for i, c := range o.buckets {
+		h.addAt(i*seconds, *c)
+	}
+	if o.timed {
+		h.last, h.timed = o.last, true
+	}
+}
+
+// Clone returns a copy of h that shares no state with it
+func (h *Histogram) Clone() *Histogram {
+	clone := *h
+	clone.buckets = make(map[int64]*Count, len(h.buckets))
+	for i, c := range h.buckets {
+		copied := *c
+		clone.buckets[i] = &copied
+	}
+	return &clone
+}
+
+// Untimed returns the lines before the first timestamp
+func (h *Histogram) Untimed() Count {
+	return h.leading
+}
+
+// Width returns the width of the buckets
+func (h *Histogram) Width() time.Duration {
+	return widths[h.width]
+}
+
+// Series is a run of consecutive, equally wide time buckets
+type Series struct {
+	Start  time.Time
+	Step   time.Duration
+	Counts []Count
+}
+
+// End returns the end of the last bucket
+func (s Series) End() time.Time {
+	return s.Start.Add(time.Duration(len(s.Counts)) * s.Step)
+}
+
+// Series returns the counts from the first to the last timestamp in at
+// most n buckets, merging adjacent buckets as needed; it is empty if no
+// line had a timestamp. Merged buckets are as wide as one of the widths
+// the Histogram itself uses, if possible.
+func (h *Histogram) Series(n int) Series {
+	if len(h.buckets) == 0 || n <= 0 {
+		return Series{}
+	}
+
+	indexes := make([]int64, 0, len(h.buckets))
+	for i := range h.buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+	first, last := indexes[0], indexes[len(indexes)-1]
+
+	span := last - first + 1
+	per := (span + int64(n) - 1) / int64(n)
+	// Prefer a column width from the ladder, such as 1m rather than 35s
+	for _, w := range widths[h.width:] {
+		if w >= time.Duration(per)*h.Width() {
+			per = int64(w / h.Width())
+			break
+		}
+	}
+	// Align columns to multiples of their width, as the buckets are
+	first = floorDiv(first, per) * per
+	columns := (last-first)/per + 1
+
+	s := Series{
+		Start:  time.Unix(first*h.seconds(), 0).UTC(),
+		Step:   time.Duration(per) * h.Width(),
+		Counts: make([]Count, columns),
+	}
+	for _, i := range indexes {
+		s.Counts[(i-first)/per].add(*h.buckets[i])
+	}
+	return s
+}
+
+func (h *Histogram) seconds() int64 {
+	return int64(widths[h.width] / time.Second)
+}
+
+func (h *Histogram) addAt(unix int64, c Count) {
+	if c.Total == 0 {
+		return
+	}
+	i := floorDiv(unix, h.seconds())
+	b := h.buckets[i]
+	if b == nil {
+		if len(h.buckets) >= maxBuckets && h.width < len(widths)-1 {
+			h.grow()
+			h.addAt(unix, c)
+			return
+		}
+		b = &Count{}
+		h.buckets[i] = b
+	}
+	b.add(c)
+}
+
+// grow moves to the next bucket width
+func (h *Histogram) grow() {
+	old := h.seconds()
+	h.width++
+	seconds := h.seconds()
+
+	buckets := make(map[int64]*Count, len(h.buckets))
+	for i, c := range h.buckets {
+		j := floorDiv(i*old, seconds)
+		if b := buckets[j]; b != nil {
+			b.add(*c)
+		} else {
+			buckets[j] = c
+		}
+	}
+	h.buckets = buckets
+
+	if len(h.buckets) > maxBuckets && h.width < len(widths)-1 {
+		h.grow()
+	}
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
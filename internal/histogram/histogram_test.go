@@ -0,0 +1,97 @@
+package histogram
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/severity"
+)
+
+var lines = []string{
+	"starting up",
+	"2024-01-31T12:00:00Z INFO ready",
+	"2024-01-31T12:00:01Z ERROR request failed",
+	"  at handler.go:42",
+	"2024-01-31T12:00:03Z INFO ok",
+	"2024-01-31T12:00:07Z WARN slow",
+}
+
+func fill(h *Histogram, lines []string) {
+	for _, line := range lines {
+		h.Add([]byte(line), line[len(line)-2:] != "ok")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := New()
+	fill(h, lines)
+
+	if got := h.Untimed(); got != (Count{Total: 1, Kept: 1}) {
+		t.Errorf("Untimed() = %+v", got)
+	}
+	if got := h.Levels[severity.Info]; got != (Count{Total: 2, Kept: 1}) {
+		t.Errorf("Levels[Info] = %+v", got)
+	}
+
+	s := h.Series(4)
+	want := Series{
+		Start: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+		Step:  5 * time.Second,
+		Counts: []Count{
+			{Total: 4, Kept: 3}, // ready, failed, its trace line and ok
+			{Total: 1, Kept: 1},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Series(4) = %+v, want %+v", s, want)
+	}
+	if s.End() != want.Start.Add(10*time.Second) {
+		t.Errorf("End() = %v", s.End())
+	}
+
+	// One column per second leaves the gaps visible
+	if s := h.Series(8); s.Step != time.Second || len(s.Counts) != 8 || s.Counts[2] != (Count{}) {
+		t.Errorf("Series(8) = %+v", s)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	whole := New()
+	fill(whole, lines)
+
+	// The trace line starts the second part and belongs to the error
+	first, second := New(), New()
+	fill(first, lines[:3])
+	fill(second, lines[3:])
+	first.Merge(second)
+
+	if !reflect.DeepEqual(first.Series(10), whole.Series(10)) || first.Levels != whole.Levels || first.Untimed() != whole.Untimed() {
+		t.Errorf("merged parts differ from the whole:\n%+v\n%+v", first.Series(10), whole.Series(10))
+	}
+}
+
+func TestGrow(t *testing.T) {
+	h := New()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2*maxBuckets; i++ {
+		line := fmt.Sprintf("%s INFO tick", start.Add(time.Duration(i)*time.Second).Format(time.RFC3339))
+		h.Add([]byte(line), true)
+	}
+
+	if h.Width() != 5*time.Second {
+		t.Errorf("Width() = %v, want 5s", h.Width())
+	}
+	total := 0
+	for _, c := range h.Series(100).Counts {
+		total += c.Total
+	}
+	if total != 2*maxBuckets {
+		t.Errorf("Series() holds %d lines, want %d", total, 2*maxBuckets)
+	}
+
+	if clone := h.Clone(); !reflect.DeepEqual(clone.Series(100), h.Series(100)) {
+		t.Error("Clone() differs")
+	}
+}
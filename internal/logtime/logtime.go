@@ -0,0 +1,127 @@
+package logtime
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// searchWindow is how far into a line a timestamp is looked for; access
+// logs put the client address before it
+const searchWindow = 128
+
+var months = map[string]time.Month{
+	"Jan": time.January, "Feb": time.February, "Mar": time.March,
+	"Apr": time.April, "May": time.May, "Jun": time.June,
+	"Jul": time.July, "Aug": time.August, "Sep": time.September,
+	"Oct": time.October, "Nov": time.November, "Dec": time.December,
+}
+
+const monthPattern = `(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)`
+
+type format struct {
+	re    *regexp.Regexp
+	parse func(m [][]byte) (time.Time, bool)
+}
+
+var formats = []format{
+	// ISO 8601 and its common variants: 2024-01-31T12:34:56.789Z,
+	// 2024-01-31 12:34:56,789 +0100
+	{
+		re: regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})[T ](\d{2}):(\d{2}):(\d{2})(?:[.,](\d{1,9}))?\s?(Z|[+-]\d{2}:?\d{2})?`),
+		parse: func(m [][]byte) (time.Time, bool) {
+			return build(m[1], month(m[2]), m[3], m[4], m[5], m[6], m[7], m[8])
+		},
+	},
+	// Common log format: [31/Jan/2024:12:34:56 +0000]
+	{
+		re: regexp.MustCompile(`(\d{2})/` + monthPattern + `/(\d{4}):(\d{2}):(\d{2}):(\d{2})(?: ([+-]\d{4}))?`),
+		parse: func(m [][]byte) (time.Time, bool) {
+			return build(m[3], months[string(m[2])], m[1], m[4], m[5], m[6], nil, m[7])
+		},
+	},
+	// Syslog: Jan 31 12:34:56, without a year
+	{
+		re: regexp.MustCompile(monthPattern + ` ([ \d]\d) (\d{2}):(\d{2}):(\d{2})(?:\.(\d{1,9}))?`),
+		parse: func(m [][]byte) (time.Time, bool) {
+			day := m[2]
+			if day[0] == ' ' {
+				day = day[1:]
+			}
+			return build(nil, months[string(m[1])], day, m[3], m[4], m[5], m[6], nil)
+		},
+	},
+}
+
+// Parser finds timestamps in log lines. It tries the format that matched
+// last first, since the lines of one file share a format. A Parser is not
+// safe for concurrent use.
+type Parser struct {
+	last int
+}
+
+// Parse returns the timestamp found near the start of line. Times
+// without a zone are taken as UTC and syslog times without a year get
+// year 0, so they only order correctly against each other.
+func (p *Parser) Parse(line []byte) (time.Time, bool) {
+	if len(line) > searchWindow {
+		line = line[:searchWindow]
+	}
+
+	for i := range formats {
+		idx := (p.last + i) % len(formats)
+		f := formats[idx]
+		m := f.re.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if t, ok := f.parse(m); ok {
+			p.last = idx
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func month(b []byte) time.Month {
+	n, _ := strconv.Atoi(string(b))
+	return time.Month(n)
+}
+
+func build(year []byte, mon time.Month, day, hour, minute, sec, frac, zone []byte) (time.Time, bool) {
+	y, _ := strconv.Atoi(string(year))
+	d, _ := strconv.Atoi(string(day))
+	h, _ := strconv.Atoi(string(hour))
+	mi, _ := strconv.Atoi(string(minute))
+	s, _ := strconv.Atoi(string(sec))
+	if mon < time.January || mon > time.December || d < 1 || d > 31 || h > 23 || mi > 59 || s > 60 {
+		return time.Time{}, false
+	}
+
+	nsec := 0
+	if len(frac) > 0 {
+		nsec, _ = strconv.Atoi(string(frac))
+		for i := len(frac); i < 9; i++ {
+			nsec *= 10
+		}
+	}
+
+	loc := time.UTC
+	if len(zone) > 0 && zone[0] != 'Z' {
+		digits := make([]byte, 0, 4)
+		for _, c := range zone[1:] {
+			if c != ':' {
+				digits = append(digits, c)
+			}
+		}
+		zh, _ := strconv.Atoi(string(digits[:2]))
+		zm, _ := strconv.Atoi(string(digits[2:]))
+		offset := zh*3600 + zm*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+
+	return time.Date(y, mon, d, h, mi, s, nsec, loc), true
+}
@@ -0,0 +1,50 @@
+package logtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{"iso utc", "2024-01-31T12:34:56.789Z INFO started", time.Date(2024, 1, 31, 12, 34, 56, 789000000, time.UTC), true},
+		{"iso offset", "2024-01-31T13:34:56+01:00 INFO", time.Date(2024, 1, 31, 12, 34, 56, 0, time.UTC), true},
+		{"iso space comma", "2024-01-31 12:34:56,5 ERROR", time.Date(2024, 1, 31, 12, 34, 56, 500000000, time.UTC), true},
+		{"bracketed", "[2024-01-31 12:34:56] app.INFO", time.Date(2024, 1, 31, 12, 34, 56, 0, time.UTC), true},
+		{"common log", `10.0.0.1 - - [31/Jan/2024:12:34:56 -0200] "GET / HTTP/1.1" 200`, time.Date(2024, 1, 31, 14, 34, 56, 0, time.UTC), true},
+		{"syslog", "Jan  5 03:04:05 host sshd[1]: ok", time.Date(0, 1, 5, 3, 4, 5, 0, time.UTC), true},
+		{"continuation", "    at com.example.Main.run(Main.java:42)", time.Time{}, false},
+		{"invalid date", "2024-13-45T99:00:00 nope", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Parser
+			got, ok := p.Parse([]byte(tt.line))
+			if ok != tt.ok {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.ok)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_RemembersFormat(t *testing.T) {
+	var p Parser
+	if _, ok := p.Parse([]byte("Jan 31 12:00:00 host a")); !ok {
+		t.Fatal("syslog line not parsed")
+	}
+	// A syslog line whose message contains an ISO date still uses the
+	// syslog timestamp at its start
+	got, ok := p.Parse([]byte("Jan 31 12:00:01 host job 2023-05-01T00:00:00Z done"))
+	if !ok || got.Second() != 1 {
+		t.Errorf("Parse() = %v, %v, want the syslog timestamp", got, ok)
+	}
+}
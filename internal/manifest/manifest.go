@@ -0,0 +1,307 @@
+// Package manifest records how a cleaned file was produced: the SHA-256 of
+// the inputs and the output, the exact filter set, the options and the
+// resulting stats. A manifest can be re-run to prove that the output still
+// follows from the recorded inputs and filters.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/discover"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+// Suffix is appended to an output path to name its manifest
+const Suffix = discover.ManifestSuffix
+
+// Version is the logcleaner version recorded in manifests; release builds
+// set it from main
+var Version = "dev"
+
+// verifyWorkers is the number of workers Verify re-runs with. The output
+// does not depend on it, so it is not recorded.
+var verifyWorkers = runtime.NumCPU()
+
+// Mode is the kind of run that produced an output
+type Mode string
+
+const (
+	ModeClean   Mode = "clean"
+	ModeMerge   Mode = "merge"
+	ModeRotated Mode = "rotated"
+	ModeInPlace Mode = "in-place"
+)
+
+// Manifest describes one cleaned output
+type Manifest struct {
+	Version  string    `json:"version"`
+	Mode     Mode      `json:"mode"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+
+	// Inputs are hashed before the run, in the order they were read
+	Inputs []File `json:"inputs"`
+	Output File   `json:"output"`
+
+	Filters       []*filter.Filter `json:"filters"`
+	FiltersSHA256 string           `json:"filters_sha256"`
+	Options       Options          `json:"options"`
+
+	// Stats leave out the histogram and samples of removed lines
+	Stats *cleaner.Stats `json:"stats"`
+}
+
+// File is a hashed input or output
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Options are the settings that affect the output of a run
+type Options struct {
+	MaxLineLength    int                    `json:"max_line_length,omitempty"`
+	LongLines        cleaner.LongLinePolicy `json:"long_lines,omitempty"`
+	Encoding         textenc.Encoding       `json:"encoding,omitempty"`
+	PreserveEncoding bool                   `json:"preserve_encoding,omitempty"`
+	DedupWindow      int                    `json:"dedup_window,omitempty"`
+	DedupExact       bool                   `json:"dedup_exact,omitempty"`
+
+	// Labels and ReorderWindow are the merge options
+	Labels        []string `json:"labels,omitempty"`
+	ReorderWindow int      `json:"reorder_window,omitempty"`
+
+	// Backup is set for in-place runs that kept the original
+	Backup bool `json:"backup,omitempty"`
+}
+
+// NewOptions takes the output-relevant settings from opts
+func NewOptions(opts cleaner.Options) Options {
+	return Options{
+		MaxLineLength:    opts.MaxLineLength,
+		LongLines:        opts.LongLines,
+		Encoding:         opts.Encoding,
+		PreserveEncoding: opts.PreserveEncoding,
+		DedupWindow:      opts.DedupWindow,
+		DedupExact:       opts.DedupExact,
+	}
+}
+
+// Path returns the manifest path for an output
+func Path(output string) string {
+	return output + Suffix
+}
+
+// Begin hashes the inputs and the filter set of a run about to start
+func Begin(mode Mode, inputs []string, filters []*filter.Filter, opts Options) (*Manifest, error) {
+	hash, err := HashFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:       Version,
+		Mode:          mode,
+		Started:       time.Now(),
+		Filters:       filters,
+		FiltersSHA256: hash,
+		Options:       opts,
+	}
+	for _, input := range inputs {
+		path, err := absPath(input)
+		if err != nil {
+			return nil, err
+		}
+		file, err := HashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		m.Inputs = append(m.Inputs, file)
+	}
+	return m, nil
+}
+
+// Finish hashes the output, records stats and writes the manifest next to
+// the output
+func (m *Manifest) Finish(output string, stats *cleaner.Stats) error {
+	path, err := absPath(output)
+	if err != nil {
+		return err
+	}
+	m.Output, err = HashFile(path)
+	if err != nil {
+		return err
+	}
+	m.Finished = time.Now()
+
+	recorded := *stats
+	recorded.Histogram = nil
+	recorded.Removed = nil
+	m.Stats = &recorded
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(Path(path), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Load reads a manifest
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Verify re-runs the manifest and confirms that the inputs are unchanged
+// and that cleaning them again yields the recorded output, which must also
+// still be on disk unchanged. All differences found are returned joined.
+func Verify(m *Manifest) error {
+	if m.Mode == ModeInPlace && !m.Options.Backup {
+		return fmt.Errorf("an in-place run without backup cannot be verified, the original is gone")
+	}
+
+	var errs []error
+	hash, err := HashFilters(m.Filters)
+	if err != nil {
+		return err
+	}
+	if hash != m.FiltersSHA256 {
+		errs = append(errs, fmt.Errorf("filter set does not match its hash"))
+	}
+
+	sources := m.sources()
+	for i, input := range m.Inputs {
+		file, err := HashFile(sources[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if file.SHA256 != input.SHA256 {
+			errs = append(errs, fmt.Errorf("input %s has changed", sources[i]))
+		}
+	}
+	// Re-running on other inputs or filters proves nothing
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	h := sha256.New()
+	if err := m.rerun(sources, h); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != m.Output.SHA256 {
+		errs = append(errs, fmt.Errorf("cleaning the inputs again yields a different output"))
+	}
+
+	file, err := HashFile(m.Output.Path)
+	if err != nil {
+		errs = append(errs, err)
+	} else if file.SHA256 != m.Output.SHA256 {
+		errs = append(errs, fmt.Errorf("output %s has changed", m.Output.Path))
+	}
+	return errors.Join(errs...)
+}
+
+// sources returns the files to read the inputs from when re-running
+func (m *Manifest) sources() []string {
+	sources := make([]string, len(m.Inputs))
+	for i, input := range m.Inputs {
+		sources[i] = input.Path
+		if m.Mode == ModeInPlace {
+			sources[i] += discover.BackupSuffix
+		}
+	}
+	return sources
+}
+
+func (m *Manifest) rerun(sources []string, w io.Writer) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("manifest lists no inputs")
+	}
+
+	opts := cleaner.Options{
+		Workers:          verifyWorkers,
+		MaxLineLength:    m.Options.MaxLineLength,
+		LongLines:        m.Options.LongLines,
+		Encoding:         m.Options.Encoding,
+		PreserveEncoding: m.Options.PreserveEncoding,
+		DedupWindow:      m.Options.DedupWindow,
+		DedupExact:       m.Options.DedupExact,
+	}
+	c := cleaner.NewWithOptions(m.Filters, opts)
+
+	var err error
+	switch m.Mode {
+	case ModeClean, ModeInPlace:
+		_, err = c.CleanTo(sources[0], w, nil)
+	case ModeMerge:
+		merge := cleaner.MergeOptions{Labels: m.Options.Labels, ReorderWindow: m.Options.ReorderWindow}
+		_, err = c.CleanMerged(sources, w, merge, nil)
+	case ModeRotated:
+		_, err = c.CleanRotated(sources, w, nil)
+	default:
+		return fmt.Errorf("unsupported run mode: %s", m.Mode)
+	}
+	return err
+}
+
+// HashFile returns the size and SHA-256 of a file
+func HashFile(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return File{Path: path, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// HashFilters returns the SHA-256 of the filter set as stored in JSON
+func HashFilters(filters []*filter.Filter) (string, error) {
+	if filters == nil {
+		filters = []*filter.Filter{}
+	}
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal filters: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// absPath resolves symlinks as cleaning in place does, so the recorded
+// paths stay valid from any working directory
+func absPath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	return filepath.Abs(resolved)
+}
@@ -0,0 +1,235 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// clean runs a single-file clean the way the CLI does and returns the
+// path of its manifest
+func clean(t *testing.T, dir string, filters []*filter.Filter) string {
+	t.Helper()
+	input := filepath.Join(dir, "app.log")
+	output := input + ".cleaned"
+	writeFile(t, input, "INFO start\nERROR disk full\nINFO done\n")
+
+	opts := cleaner.Options{DedupWindow: 1}
+	m, err := Begin(ModeClean, []string{input}, filters, NewOptions(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := cleaner.NewWithOptions(filters, opts).Clean(input, output, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Finish(output, stats); err != nil {
+		t.Fatal(err)
+	}
+	return Path(output)
+}
+
+func TestManifest_RoundTrip(t *testing.T) {
+	f, err := filter.New("errors", "^ERROR", filter.TypeRemove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := clean(t, dir, []*filter.Filter{f})
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Mode != ModeClean || m.Version != Version || m.Options.DedupWindow != 1 {
+		t.Errorf("manifest = %+v", m)
+	}
+	if len(m.Inputs) != 1 || m.Inputs[0].Path != filepath.Join(dir, "app.log") || m.Inputs[0].Size != 37 {
+		t.Errorf("Inputs = %+v", m.Inputs)
+	}
+	if m.Output.Size != int64(len("INFO start\nINFO done\n")) || len(m.Output.SHA256) != 64 {
+		t.Errorf("Output = %+v", m.Output)
+	}
+	if m.Stats.TotalLines != 3 || m.Stats.FilteredLines != 1 {
+		t.Errorf("Stats = %+v", m.Stats)
+	}
+	if len(m.Filters) != 1 || m.Filters[0].Pattern != "^ERROR" {
+		t.Errorf("Filters = %+v", m.Filters)
+	}
+
+	if err := Verify(m); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+}
+
+func TestVerify_Changes(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string, m *Manifest)
+		want   string
+	}{
+		{
+			name: "input",
+			change: func(t *testing.T, dir string, m *Manifest) {
+				writeFile(t, filepath.Join(dir, "app.log"), "INFO start\n")
+			},
+			want: "input %DIR%/app.log has changed",
+		},
+		{
+			name: "output",
+			change: func(t *testing.T, dir string, m *Manifest) {
+				writeFile(t, filepath.Join(dir, "app.log.cleaned"), "INFO start\n")
+			},
+			want: "output %DIR%/app.log.cleaned has changed",
+		},
+		{
+			name: "filters",
+			change: func(t *testing.T, dir string, m *Manifest) {
+				m.Filters[0].Pattern = "^INFO"
+			},
+			want: "filter set does not match its hash",
+		},
+		{
+			name: "filters and hash",
+			change: func(t *testing.T, dir string, m *Manifest) {
+				f, err := filter.New("errors", "^INFO", filter.TypeRemove)
+				if err != nil {
+					t.Fatal(err)
+				}
+				m.Filters[0] = f
+				m.FiltersSHA256, err = HashFilters(m.Filters)
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "yields a different output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := filter.New("errors", "^ERROR", filter.TypeRemove)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := Load(clean(t, dir, []*filter.Filter{f}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.change(t, dir, m)
+			want := strings.ReplaceAll(tt.want, "%DIR%", dir)
+			if err := Verify(m); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Verify() = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestVerify_InPlace(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "app.log")
+	writeFile(t, input, "INFO start\nERROR disk full\n")
+	f, err := filter.New("errors", "^ERROR", filter.TypeRemove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters := []*filter.Filter{f}
+
+	for _, backup := range []bool{true, false} {
+		opts := Options{Backup: backup}
+		m, err := Begin(ModeInPlace, []string{input}, filters, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats, err := cleaner.New(filters).CleanInPlace(input, cleaner.InPlaceOptions{Backup: backup}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Finish(input, stats); err != nil {
+			t.Fatal(err)
+		}
+
+		err = Verify(m)
+		if backup && err != nil {
+			t.Errorf("Verify() with backup = %v", err)
+		}
+		if !backup && err == nil {
+			t.Error("Verify() without backup succeeded")
+		}
+	}
+}
+
+func TestHashFilters(t *testing.T) {
+	empty, err := HashFilters(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := filter.New("errors", "^ERROR", filter.TypeRemove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, err := HashFilters([]*filter.Filter{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty == one {
+		t.Error("HashFilters() ignores the filters")
+	}
+	if again, _ := HashFilters([]*filter.Filter{}); again != empty {
+		t.Errorf("HashFilters(nil) = %s, HashFilters([]) = %s", empty, again)
+	}
+}
+
+func TestVerify_OtherWorkerCount(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "app.log")
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		if i%5 == 0 {
+			sb.WriteString("INFO: " + strings.Repeat("payload ", 40) + "\n")
+		} else {
+			sb.WriteString("INFO: request done\n")
+		}
+	}
+	writeFile(t, input, sb.String())
+	output := input + ".cleaned"
+
+	// Recorded sequentially, verified in parallel
+	opts := cleaner.Options{Workers: 1, MaxLineLength: 100, DedupWindow: 5}
+	m, err := Begin(ModeClean, []string{input}, nil, NewOptions(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := cleaner.NewWithOptions(nil, opts).Clean(input, output, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Finish(output, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(workers int) { verifyWorkers = workers }(verifyWorkers)
+	verifyWorkers = 4
+	if err := Verify(m); err != nil {
+		t.Errorf("Verify() with 4 workers = %v", err)
+	}
+}
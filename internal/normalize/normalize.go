@@ -0,0 +1,275 @@
+// Package normalize replaces the variable parts of log lines, such as
+// timestamps, numbers, UUIDs, hex strings and IP addresses, with
+// placeholders so that lines differing only in those parts compare equal.
+package normalize
+
+// Placeholders substituted for variable tokens
+const (
+	Timestamp = "<TS>"
+	UUID      = "<UUID>"
+	IP        = "<IP>"
+	Hex       = "<HEX>"
+	Number    = "<NUM>"
+)
+
+// minHexLength is the shortest run of hex digits taken for an identifier
+// rather than a word such as "cafe" or "added"
+const minHexLength = 6
+
+// Append appends the normalized form of line to dst. Tokens are separated
+// by whitespace and brackets, quotes, commas, semicolons, '=' and '|'; a
+// token that is a timestamp, UUID, IP address or number as a whole is
+// replaced by one placeholder, and within other tokens every run of
+// letters and digits containing a digit is replaced.
+func Append(dst, line []byte) []byte {
+	for len(line) > 0 {
+		i := 0
+		for i < len(line) && isSeparator(line[i]) {
+			i++
+		}
+		dst = append(dst, line[:i]...)
+		line = line[i:]
+
+		i = 0
+		for i < len(line) && !isSeparator(line[i]) {
+			i++
+		}
+		dst = appendToken(dst, line[:i])
+		line = line[i:]
+	}
+	return dst
+}
+
+// String returns the normalized form of line
+func String(line string) string {
+	return string(Append(nil, []byte(line)))
+}
+
+func appendToken(dst, token []byte) []byte {
+	if len(token) == 0 || !hasDigit(token) {
+		return append(dst, token...)
+	}
+
+	switch {
+	case isUUID(token):
+		return append(dst, UUID...)
+	case isIPv4(token):
+		return append(dst, IP...)
+	case isTimestamp(token):
+		return append(dst, Timestamp...)
+	case isIPv6(token):
+		return append(dst, IP...)
+	case isNumber(token):
+		return append(dst, Number...)
+	}
+
+	for len(token) > 0 {
+		i := 0
+		for i < len(token) && !isAlnum(token[i]) {
+			i++
+		}
+		dst = append(dst, token[:i]...)
+		token = token[i:]
+
+		i = 0
+		for i < len(token) && isAlnum(token[i]) {
+			i++
+		}
+		dst = appendWord(dst, token[:i])
+		token = token[i:]
+	}
+	return dst
+}
+
+// appendWord normalizes a run of letters and digits
+func appendWord(dst, word []byte) []byte {
+	switch {
+	case !hasDigit(word):
+		return append(dst, word...)
+	case isDigits(word):
+		return append(dst, Number...)
+	case len(word) >= minHexLength && isHexDigits(word):
+		return append(dst, Hex...)
+	case len(word) > 2 && word[0] == '0' && (word[1] == 'x' || word[1] == 'X') && isHexDigits(word[2:]):
+		return append(dst, Hex...)
+	}
+
+	// Keep the letters of words such as "user42" or "200ms"
+	for len(word) > 0 {
+		i := 0
+		for i < len(word) && !isDigit(word[i]) {
+			i++
+		}
+		dst = append(dst, word[:i]...)
+		word = word[i:]
+
+		i = 0
+		for i < len(word) && isDigit(word[i]) {
+			i++
+		}
+		if i > 0 {
+			dst = append(dst, Number...)
+		}
+		word = word[i:]
+	}
+	return dst
+}
+
+// isUUID matches 8-4-4-4-12 hex digits
+func isUUID(s []byte) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, b := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if b != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isIPv4 matches a dotted quad with an optional :port or /prefix
+func isIPv4(s []byte) bool {
+	for i, b := range s {
+		if b == ':' || b == '/' {
+			if !isDigits(s[i+1:]) {
+				return false
+			}
+			s = s[:i]
+			break
+		}
+	}
+
+	groups := 0
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		if i == 0 || i > 3 {
+			return false
+		}
+		groups++
+		s = s[i:]
+		if len(s) > 0 {
+			if s[0] != '.' {
+				return false
+			}
+			s = s[1:]
+			if len(s) == 0 {
+				return false
+			}
+		}
+	}
+	return groups == 4
+}
+
+// isIPv6 matches hex groups separated by at least two colons
+func isIPv6(s []byte) bool {
+	colons := 0
+	for _, b := range s {
+		switch {
+		case b == ':':
+			colons++
+		case isHexDigit(b) || b == '.':
+		default:
+			return false
+		}
+	}
+	return colons >= 2
+}
+
+// isTimestamp matches dates and times made of digits and separators, such
+// as 2024-01-31T12:34:56.789Z, 12:34:56 or 31/01/2024
+func isTimestamp(s []byte) bool {
+	if !isDigit(s[0]) {
+		return false
+	}
+	var colons, dashes, slashes int
+	for _, b := range s {
+		switch {
+		case isDigit(b), b == '.', b == ',', b == '+', b == 'T', b == 'Z':
+		case b == ':':
+			colons++
+		case b == '-':
+			dashes++
+		case b == '/':
+			slashes++
+		default:
+			return false
+		}
+	}
+	return colons >= 2 || dashes == 2 || slashes == 2
+}
+
+// isNumber matches an optionally signed integer or decimal
+func isNumber(s []byte) bool {
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+	dot := false
+	for i, b := range s {
+		if b == '.' && !dot && i > 0 && i < len(s)-1 {
+			dot = true
+			continue
+		}
+		if !isDigit(b) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func isSeparator(b byte) bool {
+	switch b {
+	case ' ', '\t', '\r', '\n', '(', ')', '[', ']', '{', '}', '<', '>', '"', '\'', ',', ';', '=', '|':
+		return true
+	}
+	return false
+}
+
+func hasDigit(s []byte) bool {
+	for _, b := range s {
+		if isDigit(b) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s []byte) bool {
+	for _, b := range s {
+		if !isDigit(b) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func isHexDigits(s []byte) bool {
+	for _, b := range s {
+		if !isHexDigit(b) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isHexDigit(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func isAlnum(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,36 @@
+package normalize
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"no variable parts", "no variable parts"},
+		{"2024-01-31T12:34:56.789Z INFO started", "<TS> INFO started"},
+		{"Jan 31 12:34:56 host sshd[1234]: accepted", "Jan <NUM> <TS> host sshd[<NUM>]: accepted"},
+		{"request 550e8400-e29b-41d4-a716-446655440000 done", "request <UUID> done"},
+		{"from 192.168.1.10:8080 and fe80::1", "from <IP> and <IP>"},
+		{"took 12.5 ms, size=-3", "took <NUM> ms, size=<NUM>"},
+		{"trace=ab12cd34ef ptr 0x7ffe", "trace=<HEX> ptr <HEX>"},
+		{"user42 got 200ms delay", "user<NUM> got <NUM>ms delay"},
+		{"txn_8f3a9b2c1d/step-3", "txn_<HEX>/step-<NUM>"},
+		{"decade added cafe", "decade added cafe"},
+		{"[31/01/2024] \"GET /a/7\"", "[<TS>] \"GET /a/<NUM>\""},
+	}
+
+	for _, tt := range tests {
+		if got := String(tt.line); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestAppend_SameShape(t *testing.T) {
+	a := String("2024-01-31 10:00:01 GET /api/users/17 200 in 35ms req=5f1c2a9e")
+	b := String("2024-02-01 23:59:59 GET /api/users/9001 200 in 4ms req=77aa01bc")
+	if a != b {
+		t.Errorf("normalized lines differ:\n%s\n%s", a, b)
+	}
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/discover"
+)
+
+// DefaultTemplate writes <file>.cleaned next to the input
+const DefaultTemplate = "{dir}/{name}{ext}" + discover.CleanedSuffix
+
+// DefaultProfile is the {profile} of a filter set without a name
+const DefaultProfile = "default"
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+var placeholders = map[string]bool{
+	"{dir}": true, "{name}": true, "{ext}": true, "{date}": true, "{profile}": true,
+}
+
+// Namer derives output paths from input paths with a template. The
+// placeholders are {dir}, the input's directory or Dir if set; {name}, the
+// file name without its last extension; {ext}, that extension including
+// the dot; {date}, today as 2006-01-02; and {profile}, the filter profile.
+type Namer struct {
+	template string
+	dir      string
+	profile  string
+	now      func() time.Time
+}
+
+// NewNamer validates template, using DefaultTemplate if it is empty
+func NewNamer(template, dir, profile string) (*Namer, error) {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	for _, p := range placeholderPattern.FindAllString(template, -1) {
+		if !placeholders[p] {
+			return nil, fmt.Errorf("unknown placeholder %s in output template", p)
+		}
+	}
+	if !strings.Contains(template, "{name}") {
+		return nil, fmt.Errorf("output template must contain {name}")
+	}
+	// Without an output directory this names each input itself
+	if dir == "" && filepath.Clean(template) == filepath.Join("{dir}", "{name}{ext}") {
+		return nil, fmt.Errorf("output template %s names each input itself", template)
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	return &Namer{template: template, dir: dir, profile: profile, now: time.Now}, nil
+}
+
+// Path returns the output path for input
+func (n *Namer) Path(input string) string {
+	dir := filepath.Dir(input)
+	if n.dir != "" {
+		dir = n.dir
+	}
+	base := filepath.Base(input)
+	ext := filepath.Ext(base)
+
+	r := strings.NewReplacer(
+		"{dir}", dir,
+		"{name}", strings.TrimSuffix(base, ext),
+		"{ext}", ext,
+		"{date}", n.now().Format("2006-01-02"),
+		"{profile}", n.profile,
+	)
+	return filepath.Clean(r.Replace(n.template))
+}
+
+// WithoutOutputs drops the inputs that are the output of another input, as
+// left by an earlier run with the same template
+func (n *Namer) WithoutOutputs(inputs []string) []string {
+	outputs := make(map[string]int, len(inputs))
+	for i, input := range inputs {
+		if path, err := filepath.Abs(n.Path(input)); err == nil {
+			outputs[path] = i
+		}
+	}
+
+	var kept []string
+	for i, input := range inputs {
+		path, err := filepath.Abs(input)
+		if j, ok := outputs[path]; err == nil && ok && j != i {
+			continue
+		}
+		kept = append(kept, input)
+	}
+	return kept
+}
+
+// MergedPath returns the output path for several inputs merged into one,
+// named as if they were a file merged.log next to the first input
+func (n *Namer) MergedPath(inputs []string) string {
+	return n.Path(filepath.Join(filepath.Dir(inputs[0]), "merged.log"))
+}
+
+// Existing returns the paths that already exist
+func Existing(paths []string) []string {
+	var existing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	return existing
+}
+
+// Overwritten returns an output that is one of inputs, also through
+// symlinks, hard links or differently written paths, and that input
+func Overwritten(outputs, inputs []string) (output, input string, ok bool) {
+	abs := make(map[string]string, len(inputs))
+	infos := make(map[string]os.FileInfo, len(inputs))
+	for _, in := range inputs {
+		if path, err := filepath.Abs(in); err == nil {
+			abs[path] = in
+		}
+		if info, err := os.Stat(in); err == nil {
+			infos[in] = info
+		}
+	}
+
+	for _, out := range outputs {
+		if path, err := filepath.Abs(out); err == nil && abs[path] != "" {
+			return out, abs[path], true
+		}
+		info, err := os.Stat(out)
+		if err != nil {
+			continue
+		}
+		for in, inInfo := range infos {
+			if os.SameFile(info, inInfo) {
+				return out, in, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Collision returns two inputs whose outputs, given in the same order, are
+// the same file, as for equally named files written to one directory
+func Collision(inputs, outputs []string) (a, b, output string, ok bool) {
+	seen := make(map[string]int, len(outputs))
+	for i, out := range outputs {
+		path, err := filepath.Abs(out)
+		if err != nil {
+			path = out
+		}
+		if j, dup := seen[path]; dup {
+			return inputs[j], inputs[i], out, true
+		}
+		seen[path] = i
+	}
+	return "", "", "", false
+}
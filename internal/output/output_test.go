@@ -0,0 +1,122 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNamer(t *testing.T) {
+	input := filepath.Join("var", "log", "app.log")
+
+	tests := []struct {
+		name     string
+		template string
+		dir      string
+		want     string
+	}{
+		{"default", "", "", filepath.Join("var", "log", "app.log.cleaned")},
+		{"output dir", "", "out", filepath.Join("out", "app.log.cleaned")},
+		{"all placeholders", "{dir}/{profile}/{name}-{date}{ext}", "", filepath.Join("var", "log", "nginx", "app-2024-01-31.log")},
+		{"relative", "{name}.clean{ext}", "", "app.clean.log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNamer(tt.template, tt.dir, "nginx")
+			if err != nil {
+				t.Fatalf("NewNamer() error = %v", err)
+			}
+			n.now = func() time.Time { return time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC) }
+
+			if got := n.Path(input); got != tt.want {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNamer_Invalid(t *testing.T) {
+	for _, template := range []string{"{dir}/{nmae}.log", "{dir}/out.log", "{dir}/{name}{ext}", "{dir}//{name}{ext}"} {
+		if _, err := NewNamer(template, "", ""); err == nil {
+			t.Errorf("NewNamer(%q) should fail", template)
+		}
+	}
+	if _, err := NewNamer("{dir}/{name}{ext}", "out", ""); err != nil {
+		t.Errorf("NewNamer() with an output directory: %v", err)
+	}
+}
+
+func TestMergedPath(t *testing.T) {
+	n, _ := NewNamer("", "", "")
+	got := n.MergedPath([]string{filepath.Join("logs", "a.log"), filepath.Join("other", "b.log")})
+	if want := filepath.Join("logs", "merged.log.cleaned"); got != want {
+		t.Errorf("MergedPath() = %q, want %q", got, want)
+	}
+}
+
+func TestExisting(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.log")
+	if err := os.WriteFile(present, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := Existing([]string{present, filepath.Join(dir, "missing.log")})
+	if !reflect.DeepEqual(got, []string{present}) {
+		t.Errorf("Existing() = %v", got)
+	}
+}
+
+func TestOverwritten(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(dir, "link.log")
+	if err := os.Symlink(b, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	inputs := []string{a, b}
+	for _, out := range []string{filepath.Join(dir, ".", "b.log"), link} {
+		if got, input, ok := Overwritten([]string{filepath.Join(dir, "new.log"), out}, inputs); !ok || got != out || input != b {
+			t.Errorf("Overwritten(%s) = %s, %s, %v", out, got, input, ok)
+		}
+	}
+	if _, _, ok := Overwritten([]string{filepath.Join(dir, "new.log"), a + ".cleaned"}, inputs); ok {
+		t.Error("Overwritten() found an input among new outputs")
+	}
+}
+
+func TestCollision(t *testing.T) {
+	n, _ := NewNamer("", "out", "")
+	inputs := []string{filepath.Join("a", "app.log"), filepath.Join("a", "db.log"), filepath.Join("b", "app.log")}
+	outputs := make([]string, len(inputs))
+	for i, input := range inputs {
+		outputs[i] = n.Path(input)
+	}
+
+	a, b, out, ok := Collision(inputs, outputs)
+	if !ok || a != inputs[0] || b != inputs[2] || out != filepath.Join("out", "app.log.cleaned") {
+		t.Errorf("Collision() = %s, %s, %s, %v", a, b, out, ok)
+	}
+	if _, _, _, ok := Collision(inputs[:2], outputs[:2]); ok {
+		t.Error("Collision() without equal names")
+	}
+}
+
+func TestWithoutOutputs(t *testing.T) {
+	n, _ := NewNamer("{dir}/{name}.clean{ext}", "", "")
+	inputs := []string{"app.clean.log", "app.log", "db.log", "other.clean.log"}
+
+	// other.clean.log has no input of its own and is kept
+	if got, want := n.WithoutOutputs(inputs), []string{"app.log", "db.log", "other.clean.log"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WithoutOutputs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Package report renders the outcome of a cleaning run as a self-contained
+// Markdown or HTML document: the files, the filters with their hits and
+// samples of the lines they removed, and the lines over time.
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/severity"
+)
+
+// Format is the document format of a report
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+// Samples is the number of removed lines per filter a report shows
+const Samples = 5
+
+// timelineColumns is the number of time buckets a report shows
+const timelineColumns = 60
+
+// FormatFor returns the format for a report path by its extension
+func FormatFor(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return Markdown, nil
+	case ".html", ".htm":
+		return HTML, nil
+	}
+	return "", fmt.Errorf("unknown report format for %s: use .md or .html", path)
+}
+
+// File is a path and its size in bytes, -1 if unknown
+type File struct {
+	Path string
+	Size int64
+}
+
+// Stat returns the files at paths with their current sizes
+func Stat(paths ...string) []File {
+	files := make([]File, len(paths))
+	for i, path := range paths {
+		files[i] = File{Path: path, Size: -1}
+		if info, err := os.Stat(path); err == nil {
+			files[i].Size = info.Size()
+		}
+	}
+	return files
+}
+
+// Report describes a finished run
+type Report struct {
+	Generated time.Time
+	Inputs    []File
+	Outputs   []File
+	Filters   []*filter.Filter
+	Stats     *cleaner.Stats
+}
+
+// Write renders r to w in format
+func Write(w io.Writer, r *Report, format Format) error {
+	v := newView(r)
+	var err error
+	switch format {
+	case Markdown:
+		err = markdownTemplate.Execute(w, v)
+	case HTML:
+		err = htmlTemplate.Execute(w, v)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes r to path in the format given by its extension
+func WriteFile(path string, r *Report) error {
+	format, err := FormatFor(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if err := Write(f, r, format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// sparkBlocks are the sparkline glyphs from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values scaled to peak, one glyph per value; zero
+// stays blank so gaps in the log are visible
+func Sparkline(values []int, peak int) string {
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 || peak <= 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		i := (v*len(sparkBlocks) - 1) / peak
+		if i >= len(sparkBlocks) {
+			i = len(sparkBlocks) - 1
+		}
+		sb.WriteRune(sparkBlocks[i])
+	}
+	return sb.String()
+}
+
+// TypeLabel describes the type of f with its threshold or rate
+func TypeLabel(f *filter.Filter) string {
+	switch f.Type {
+	case filter.TypeFrequency:
+		return fmt.Sprintf("frequency >%d", f.Threshold)
+	case filter.TypeSample:
+		if f.RateLimited() {
+			return fmt.Sprintf("sample %d per %s", f.Limit, time.Duration(f.Interval))
+		}
+		return fmt.Sprintf("sample 1 in %d", f.Rate)
+	}
+	return string(f.Type)
+}
+
+// levelOrder lists the levels from most to least severe
+var levelOrder = []severity.Level{
+	severity.Fatal, severity.Error, severity.Warn, severity.Info,
+	severity.Debug, severity.Trace, severity.Unknown,
+}
+
+// formatSize returns size in B, KiB, MiB or GiB
+func formatSize(size int64) string {
+	if size < 0 {
+		return "-"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, prefix := float64(size)/unit, 0
+	for value >= unit && prefix < 2 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMG"[prefix])
+}
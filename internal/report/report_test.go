@@ -0,0 +1,134 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func newTestReport(t *testing.T) *Report {
+	t.Helper()
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "app.log")
+	outputPath := filepath.Join(dir, "app.log.cleaned")
+	content := "2024-01-31T12:00:00Z INFO ready\n" +
+		"2024-01-31T12:00:01Z DEBUG a|b <tag>\n" +
+		"2024-01-31T12:00:02Z ERROR failed\n" +
+		"2024-01-31T12:03:00Z DEBUG ```\n"
+	if err := os.WriteFile(inputPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	debug, _ := filter.New("debug | noise", "DEBUG", filter.TypeRemove)
+	noise, _ := filter.NewFrequency("noise", "", 100, filter.Options{})
+	filters := []*filter.Filter{debug, noise}
+	c := cleaner.NewWithOptions(filters, cleaner.Options{Histogram: true, RemovedSamples: Samples})
+	stats, err := c.Clean(inputPath, outputPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Report{
+		Generated: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+		Inputs:    Stat(inputPath),
+		Outputs:   Stat(outputPath),
+		Filters:   filters,
+		Stats:     stats,
+	}
+}
+
+func TestWrite_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, newTestReport(t), Markdown); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"Generated 2024-02-01T09:00:00Z",
+		"app.log | 134 B |",
+		"| Lines filtered | 2 |",
+		"| 1 | debug \\| noise | remove | `DEBUG` |  | 2 |",
+		"| 2 | noise | frequency >100 | (all lines) |  | 0 |",
+		"### Removed by debug \\| noise\n",
+		"````\n2024-01-31T12:00:01Z DEBUG a|b <tag>\n2024-01-31T12:03:00Z DEBUG ```\n````",
+		"2024-01-31 12:00:00 – 2024-01-31 12:03:05, 5s per column",
+		"Before █" + strings.Repeat(" ", 35) + "▃\n",
+		"After  ▆" + strings.Repeat(" ", 36) + "\n",
+		"| ERROR | 1 | 1 | 0 |",
+		"| DEBUG | 2 | 0 | 2 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWrite_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, newTestReport(t), HTML); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<td>debug | noise</td>",
+		"DEBUG a|b &lt;tag&gt;",
+		`title="2024-01-31 12:00:00: 3 lines, 2 kept"`,
+		`<div class="bar" style="height: 100.0%"`,
+		"<td>DEBUG</td><td class=\"num\">2</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<tag>") {
+		t.Error("log lines must be escaped")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := WriteFile(path, newTestReport(t)); err == nil {
+		t.Error("WriteFile() accepted an unknown extension")
+	}
+
+	for _, name := range []string{"report.md", "report.HTML"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := WriteFile(path, newTestReport(t)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("WriteFile(%s) wrote nothing", name)
+		}
+	}
+}
+
+func TestHeading(t *testing.T) {
+	if got, want := heading("*tmp_* #1\n[x]"), `\*tmp\_\* \#1 \[x\]`; got != want {
+		t.Errorf("heading() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, want := range map[int64]string{-1: "-", 512: "512 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB", 3 << 30: "3.0 GiB"} {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]int{0, 1, 4, 8}, 8); got != " ▁▄█" {
+		t.Errorf("Sparkline() = %q", got)
+	}
+	if got := Sparkline([]int{3}, 0); got != " " {
+		t.Errorf("Sparkline() without peak = %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	htmltemplate "html/template"
+	"text/template"
+)
+
+var funcs = map[string]any{
+	"cell":    cell,
+	"code":    code,
+	"fence":   fence,
+	"heading": heading,
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(funcs).Parse(`# Log cleaning report
+
+Generated {{.Generated}}
+
+## Files
+
+| | Path | Size |
+|---|---|---:|
+{{range .Files}}| {{.Role}} | {{cell .Path}} | {{.Size}} |
+{{end}}
+## Summary
+
+| | Lines |
+|---|---:|
+{{range .Summary}}| {{.Label}} | {{.Value}} |
+{{end}}
+## Filters
+{{if .Filters}}
+| # | Name | Type | Pattern | Options | Removed |
+|---:|---|---|---|---|---:|
+{{range .Filters}}| {{.Index}} | {{cell .Name}} | {{.Type}} | {{if .Pattern}}{{code .Pattern}}{{else}}(all lines){{end}} | {{.Options}} | {{.Hits}} |
+{{end}}{{range .Filters}}{{if .Samples}}
+### Removed by {{heading .Name}}
+
+{{fence .Samples}}
+{{range .Samples}}{{.}}
+{{end}}{{fence .Samples}}
+{{end}}{{end}}{{else}}
+No filters were used.
+{{end}}{{with .Timeline}}
+## Lines over time
+
+{{.Range}}, {{.Step}} per column
+
+` + "```" + `
+Before {{.Before}}
+After  {{.After}}
+` + "```" + `
+
+Peak: {{.Peak}}{{if .Untimed}}; {{.Untimed}} lines before the first timestamp{{end}}
+{{end}}{{if .Levels}}
+## Levels
+
+| Level | Lines | Kept | Removed |
+|---|---:|---:|---:|
+{{range .Levels}}| {{.Level}} | {{.Total}} | {{.Kept}} | {{.Removed}} |
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Log cleaning report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+h1, h2, h3 { color: #7D56F4; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+td.num, th.num { text-align: right; }
+code, pre { font-family: ui-monospace, monospace; background: #f5f3fc; }
+pre { padding: 0.6em; overflow-x: auto; }
+.chart { display: flex; align-items: flex-end; height: 8em; gap: 1px; border-bottom: 1px solid #999; }
+.bar { flex: 1; position: relative; background: #d9d2f7; }
+.bar div { position: absolute; bottom: 0; width: 100%; background: #7D56F4; }
+.legend { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Log cleaning report</h1>
+<p class="legend">Generated {{.Generated}}</p>
+
+<h2>Files</h2>
+<table>
+<tr><th></th><th>Path</th><th class="num">Size</th></tr>
+{{range .Files}}<tr><td>{{.Role}}</td><td>{{.Path}}</td><td class="num">{{.Size}}</td></tr>
+{{end}}</table>
+
+<h2>Summary</h2>
+<table>
+{{range .Summary}}<tr><td>{{.Label}}</td><td class="num">{{.Value}}</td></tr>
+{{end}}</table>
+
+<h2>Filters</h2>
+{{if .Filters}}<table>
+<tr><th class="num">#</th><th>Name</th><th>Type</th><th>Pattern</th><th>Options</th><th class="num">Removed</th></tr>
+{{range .Filters}}<tr><td class="num">{{.Index}}</td><td>{{.Name}}</td><td>{{.Type}}</td><td>{{if .Pattern}}<code>{{.Pattern}}</code>{{else}}(all lines){{end}}</td><td>{{.Options}}</td><td class="num">{{.Hits}}</td></tr>
+{{end}}</table>
+{{range .Filters}}{{if .Samples}}<h3>Removed by {{.Name}}</h3>
+<pre>{{range .Samples}}{{.}}
+{{end}}</pre>
+{{end}}{{end}}{{else}}<p>No filters were used.</p>
+{{end}}
+{{with .Timeline}}<h2>Lines over time</h2>
+<p class="legend">{{.Range}}, {{.Step}} per column; light: all lines, dark: kept lines</p>
+<div class="chart">
+{{range .Columns}}<div class="bar" style="height: {{printf "%.1f" .TotalHeight}}%" title="{{.Start}}: {{.Total}} lines, {{.Kept}} kept"><div style="height: {{printf "%.1f" .KeptPercent}}%"></div></div>
+{{end}}</div>
+<p>Peak: {{.Peak}}{{if .Untimed}}; {{.Untimed}} lines before the first timestamp{{end}}</p>
+{{end}}
+{{if .Levels}}<h2>Levels</h2>
+<table>
+<tr><th>Level</th><th class="num">Lines</th><th class="num">Kept</th><th class="num">Removed</th></tr>
+{{range .Levels}}<tr><td>{{.Level}}</td><td class="num">{{.Total}}</td><td class="num">{{.Kept}}</td><td class="num">{{.Removed}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,226 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// view is the data both templates render
+type view struct {
+	Generated string
+	Files     []fileRow
+	Summary   []summaryRow
+	Filters   []filterRow
+	Timeline  *timeline
+	Levels    []levelRow
+}
+
+type fileRow struct {
+	Role string
+	Path string
+	Size string
+}
+
+type summaryRow struct {
+	Label string
+	Value int
+}
+
+type filterRow struct {
+	Index   int
+	Name    string
+	Type    string
+	Pattern string
+	Options string
+	Hits    int
+	Samples []string
+}
+
+type timeline struct {
+	Range   string
+	Step    string
+	Before  string
+	After   string
+	Peak    string
+	Untimed int
+	Columns []column
+}
+
+// column is a time bucket; TotalHeight is the share of the peak and
+// KeptPercent the share of the column's lines that were kept
+type column struct {
+	Start       string
+	Total       int
+	Kept        int
+	TotalHeight float64
+	KeptPercent float64
+}
+
+type levelRow struct {
+	Level   string
+	Total   int
+	Kept    int
+	Removed int
+}
+
+func newView(r *Report) *view {
+	v := &view{Generated: r.Generated.Format(time.RFC3339)}
+
+	for _, f := range r.Inputs {
+		v.Files = append(v.Files, fileRow{Role: "Input", Path: f.Path, Size: formatSize(f.Size)})
+	}
+	for _, f := range r.Outputs {
+		v.Files = append(v.Files, fileRow{Role: "Output", Path: f.Path, Size: formatSize(f.Size)})
+	}
+
+	s := r.Stats
+	if s == nil {
+		return v
+	}
+	v.Summary = []summaryRow{
+		{"Lines read", s.TotalLines},
+		{"Lines filtered", s.FilteredLines},
+	}
+	for _, row := range []summaryRow{
+		{"Sampled out", s.SampledOut},
+		{"Repeats collapsed", s.DuplicateLines},
+		{"Long lines", s.LongLines},
+		{"Long lines dropped", s.DroppedLongLines},
+		{"Overlapping lines skipped", s.OverlapLines},
+	} {
+		if row.Value > 0 {
+			v.Summary = append(v.Summary, row)
+		}
+	}
+	v.Summary = append(v.Summary, summaryRow{"Lines remaining", s.RemainingLines()})
+
+	for i, f := range r.Filters {
+		row := filterRow{
+			Index:   i + 1,
+			Name:    f.Name,
+			Type:    TypeLabel(f),
+			Pattern: f.Pattern,
+			Options: f.Options.String(),
+		}
+		if i < len(s.FilterHits) {
+			row.Hits = s.FilterHits[i]
+		}
+		if i < len(s.Removed) {
+			row.Samples = s.Removed[i]
+		}
+		v.Filters = append(v.Filters, row)
+	}
+
+	if s.Histogram != nil {
+		v.Timeline = newTimeline(r)
+		for _, level := range levelOrder {
+			if c := s.Histogram.Levels[level]; c.Total > 0 {
+				v.Levels = append(v.Levels, levelRow{Level: level.String(), Total: c.Total, Kept: c.Kept, Removed: c.Removed()})
+			}
+		}
+	}
+
+	return v
+}
+
+func newTimeline(r *Report) *timeline {
+	h := r.Stats.Histogram
+	series := h.Series(timelineColumns)
+	if len(series.Counts) == 0 {
+		return nil
+	}
+
+	total := make([]int, len(series.Counts))
+	kept := make([]int, len(series.Counts))
+	peak := 0
+	for i, c := range series.Counts {
+		total[i], kept[i] = c.Total, c.Kept
+		if c.Total > series.Counts[peak].Total {
+			peak = i
+		}
+	}
+	highest := series.Counts[peak].Total
+
+	const layout = "2006-01-02 15:04:05"
+	t := &timeline{
+		Range:   series.Start.Format(layout) + " – " + series.End().Format(layout),
+		Step:    series.Step.String(),
+		Before:  Sparkline(total, highest),
+		After:   Sparkline(kept, highest),
+		Untimed: h.Untimed().Total,
+		Peak: fmt.Sprintf("%d lines at %s, %d kept", highest,
+			series.Start.Add(time.Duration(peak)*series.Step).Format(layout), series.Counts[peak].Kept),
+	}
+	for i, c := range series.Counts {
+		t.Columns = append(t.Columns, column{
+			Start:       series.Start.Add(time.Duration(i) * series.Step).Format(layout),
+			Total:       c.Total,
+			Kept:        c.Kept,
+			TotalHeight: percent(c.Total, highest),
+			KeptPercent: percent(c.Kept, c.Total),
+		})
+	}
+	return t
+}
+
+func percent(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(of)
+}
+
+// fence returns a code fence longer than any backtick run in lines
+func fence(lines []string) string {
+	longest := 0
+	for _, line := range lines {
+		run := 0
+		for _, r := range line {
+			if r == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// cell escapes text for a Markdown table cell
+func cell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(text)
+}
+
+// heading escapes text for a Markdown heading, so that filter names are
+// shown as written
+func heading(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r':
+			r = ' '
+		case r < 0x80 && strings.ContainsRune("\\`*_{}[]<>()#+-.!|~", r):
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// code renders text as inline Markdown code, escaped for a table cell
+func code(text string) string {
+	if text == "" {
+		return ""
+	}
+	ticks := "`"
+	for strings.Contains(text, ticks) {
+		ticks += "`"
+	}
+	pad := ""
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		pad = " "
+	}
+	return ticks + pad + cell(text) + pad + ticks
+}
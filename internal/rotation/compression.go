@@ -0,0 +1,58 @@
+package rotation
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// compressions lists the formats recognised by Compression with their
+// magic bytes and usual extension. Only gzip is decompressed by Open.
+var compressions = []struct {
+	name  string
+	magic []byte
+	ext   string
+}{
+	{"gzip", []byte{0x1f, 0x8b}, ".gz"},
+	{"bzip2", []byte("BZh"), ".bz2"},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, ".xz"},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, ".zst"},
+	{"lz4", []byte{0x04, 0x22, 0x4d, 0x18}, ".lz4"},
+	{"zip", []byte("PK\x03\x04"), ".zip"},
+}
+
+// Compression names the compression format of a file by its content, or
+// returns "" if it is not compressed
+func Compression(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 6)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	for _, c := range compressions {
+		if bytes.HasPrefix(head[:n], c.magic) {
+			return c.name, nil
+		}
+	}
+	return "", nil
+}
+
+// CompressionByName guesses the compression format of a file from its
+// extension, for when reading it is too costly
+func CompressionByName(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, c := range compressions {
+		if ext == c.ext {
+			return c.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,248 @@
+package rotation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	// maxOverlapLines bounds how far back an overlap is searched for
+	maxOverlapLines = 1000
+	// maxHeadBytes bounds how much of a file is buffered while searching
+	maxHeadBytes = 4 * 1024 * 1024
+)
+
+// lineKey identifies a line by hash and length of its content
+type lineKey struct {
+	hash   uint64
+	length int
+}
+
+// Joined reads the members of a rotation set as one stream. Lines at the
+// start of a member that repeat the end of the previous one, as left behind
+// by copytruncate when the copy and the truncation race with the writer,
+// are skipped. A member not ending with a line break gets one so its last
+// line is not joined with the first line of the next member.
+type Joined struct {
+	open  func(i int) (io.ReadCloser, error)
+	count int
+	next  int
+
+	cur     io.ReadCloser
+	br      *bufio.Reader
+	pending []byte
+	hasData bool
+	last    byte
+
+	// tail holds the last lines passed on, partial the line in progress
+	tail    []lineKey
+	partial lineKey
+	skipped int
+}
+
+// NewJoined joins count members opened in order by open
+func NewJoined(count int, open func(i int) (io.ReadCloser, error)) *Joined {
+	return &Joined{open: open, count: count, partial: lineKey{hash: offset64}}
+}
+
+// Skipped returns the number of overlapping lines skipped so far
+func (j *Joined) Skipped() int {
+	return j.skipped
+}
+
+func (j *Joined) Read(p []byte) (int, error) {
+	for {
+		if len(j.pending) > 0 {
+			n := copy(p, j.pending)
+			j.pending = j.pending[n:]
+			return n, nil
+		}
+
+		if j.cur == nil {
+			if j.next == j.count {
+				return 0, io.EOF
+			}
+			if err := j.openNext(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		n, err := j.br.Read(p)
+		if n > 0 {
+			j.observe(p[:n])
+			return n, nil
+		}
+		if err == io.EOF {
+			j.closeCurrent()
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+}
+
+// Close closes the member currently being read
+func (j *Joined) Close() error {
+	if j.cur == nil {
+		return nil
+	}
+	err := j.cur.Close()
+	j.cur = nil
+	return err
+}
+
+func (j *Joined) openNext() error {
+	rc, err := j.open(j.next)
+	if err != nil {
+		return err
+	}
+	j.cur = rc
+	j.br = bufio.NewReaderSize(rc, 64*1024)
+	j.hasData = false
+	j.next++
+
+	if j.next == 1 {
+		return nil
+	}
+
+	head, ends, err := j.readHead()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]lineKey, len(ends))
+	start := 0
+	for i, end := range ends {
+		keys[i] = keyOf(head[start:end])
+		start = end
+	}
+
+	skip := 0
+	if k := overlap(j.tail, keys); k > 0 {
+		skip = ends[k-1]
+		j.skipped += k
+	}
+
+	j.observe(head)
+	j.pending = head[skip:]
+	return nil
+}
+
+// readHead buffers the first lines of the current member and returns them
+// with the end offset of each complete line. An unterminated last line at
+// the end of the member counts as complete.
+func (j *Joined) readHead() ([]byte, []int, error) {
+	var head []byte
+	var ends []int
+	for len(ends) < maxOverlapLines && len(head) < maxHeadBytes {
+		line, err := j.br.ReadSlice('\n')
+		head = append(head, line...)
+		if err == bufio.ErrBufferFull {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if len(line) > 0 {
+			ends = append(ends, len(head))
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return head, ends, nil
+}
+
+func (j *Joined) closeCurrent() {
+	j.cur.Close()
+	j.cur = nil
+
+	if j.partial.length > 0 {
+		j.pushTail()
+	}
+	if j.hasData && j.last != '\n' && j.next < j.count {
+		j.pending = []byte{'\n'}
+	}
+}
+
+// observe records the lines of data passed on so the end of a member can
+// be compared with the start of the next one
+func (j *Joined) observe(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	j.hasData = true
+	j.last = data[len(data)-1]
+
+	for len(data) > 0 {
+		i := 0
+		for i < len(data) && data[i] != '\n' {
+			i++
+		}
+		j.partial.hash = fnvAppend(j.partial.hash, data[:i])
+		j.partial.length += i
+		if i == len(data) {
+			return
+		}
+
+		j.pushTail()
+		data = data[i+1:]
+	}
+}
+
+// pushTail completes the line in progress
+func (j *Joined) pushTail() {
+	if len(j.tail) == maxOverlapLines {
+		copy(j.tail, j.tail[1:])
+		j.tail = j.tail[:len(j.tail)-1]
+	}
+	j.tail = append(j.tail, j.partial)
+	j.partial = lineKey{hash: offset64}
+}
+
+// overlap returns the largest k such that the last k lines of tail equal
+// the first k lines of head and at least one of them is not blank
+func overlap(tail, head []lineKey) int {
+	for k := min(len(tail), len(head)); k > 0; k-- {
+		suffix := tail[len(tail)-k:]
+		equal, blank := true, true
+		for i := range suffix {
+			if suffix[i] != head[i] {
+				equal = false
+				break
+			}
+			if head[i].length > 0 {
+				blank = false
+			}
+		}
+		if equal && !blank {
+			return k
+		}
+	}
+	return 0
+}
+
+// keyOf returns the key of a single line with or without its terminator
+func keyOf(line []byte) lineKey {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	return lineKey{hash: fnvAppend(offset64, line), length: len(line)}
+}
+
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+// fnvAppend continues an FNV-1a hash so a line can be hashed piece by piece
+func fnvAppend(h uint64, p []byte) uint64 {
+	for _, b := range p {
+		h ^= uint64(b)
+		h *= prime64
+	}
+	return h
+}
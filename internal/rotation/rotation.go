@@ -0,0 +1,111 @@
+package rotation
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// suffixPattern matches what logrotate appends to a rotated file: a number
+// (app.log.1) or a date (app.log-20240131, app.log.2024-01-31), optionally
+// followed by .gz
+var suffixPattern = regexp.MustCompile(`^(?:\.(\d+)|[.-](\d{8}(?:\d{2})?|\d{4}-\d{2}-\d{2}))(?:\.gz)?$`)
+
+type member struct {
+	path string
+	num  int
+	date string
+}
+
+// Discover returns the rotation set of base, the current log file, ordered
+// from the oldest rotated file to base itself. Date-stamped files are taken
+// to be older than numbered ones, which count up with age.
+func Discover(base string) ([]string, error) {
+	dir, name := filepath.Split(base)
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var dated, numbered []member
+	current := false
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), name) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		suffix := strings.TrimPrefix(entry.Name(), name)
+		if suffix == "" {
+			current = true
+			continue
+		}
+
+		m := suffixPattern.FindStringSubmatch(suffix)
+		switch {
+		case m == nil:
+		case m[1] != "":
+			num, _ := strconv.Atoi(m[1])
+			numbered = append(numbered, member{path: path, num: num})
+		default:
+			dated = append(dated, member{path: path, date: strings.ReplaceAll(m[2], "-", "")})
+		}
+	}
+
+	sort.Slice(dated, func(i, j int) bool { return dated[i].date < dated[j].date })
+	sort.Slice(numbered, func(i, j int) bool { return numbered[i].num > numbered[j].num })
+
+	var paths []string
+	for _, m := range dated {
+		paths = append(paths, m.path)
+	}
+	for _, m := range numbered {
+		paths = append(paths, m.path)
+	}
+	if current {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no log files found for %s", base)
+	}
+	return paths, nil
+}
+
+// Open opens a member of a rotation set, decompressing it if it is gzipped.
+// Compression is recognised by content, not by file name.
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	br := bufio.NewReader(file)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return readCloser{br, file}, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return readCloser{gz, file}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
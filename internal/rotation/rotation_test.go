@@ -0,0 +1,164 @@
+package rotation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"app.log", "app.log.1", "app.log.2.gz", "app.log.10.gz",
+		"app.log-20240102", "app.log-20240101.gz",
+		"app.log.cleaned", "app.logger", "other.log.1",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Discover(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	var want []string
+	for _, name := range []string{"app.log-20240101.gz", "app.log-20240102", "app.log.10.gz", "app.log.2.gz", "app.log.1", "app.log"} {
+		want = append(want, filepath.Join(dir, name))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discover() = %v, want %v", got, want)
+	}
+
+	if _, err := Discover(filepath.Join(dir, "missing.log")); err == nil {
+		t.Error("Discover() of a missing base should fail")
+	}
+}
+
+func TestOpen_Gzip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.2") // compressed despite the name
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("compressed line\n"))
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer rc.Close()
+
+	got, _ := io.ReadAll(rc)
+	if string(got) != "compressed line\n" {
+		t.Errorf("Open() content = %q", got)
+	}
+}
+
+func TestJoined(t *testing.T) {
+	tests := []struct {
+		name        string
+		members     []string
+		want        string
+		wantSkipped int
+	}{
+		{
+			name:    "no overlap",
+			members: []string{"a\nb\n", "c\nd\n"},
+			want:    "a\nb\nc\nd\n",
+		},
+		{
+			name:        "copytruncate overlap",
+			members:     []string{"a\nb\nc\n", "b\nc\nd\n"},
+			want:        "a\nb\nc\nd\n",
+			wantSkipped: 2,
+		},
+		{
+			name:        "whole member repeated",
+			members:     []string{"a\nb\n", "a\nb\nc\n"},
+			want:        "a\nb\nc\n",
+			wantSkipped: 2,
+		},
+		{
+			name:    "blank lines are no overlap",
+			members: []string{"a\n\n", "\nb\n"},
+			want:    "a\n\n\nb\n",
+		},
+		{
+			name:    "missing final newline",
+			members: []string{"a\nb", "c\n"},
+			want:    "a\nb\nc\n",
+		},
+		{
+			name:        "empty member",
+			members:     []string{"a\n", "", "a\nb"},
+			want:        "a\nb",
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJoined(len(tt.members), func(i int) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(tt.members[i])), nil
+			})
+
+			got, err := io.ReadAll(j)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("joined = %q, want %q", got, tt.want)
+			}
+			if j.Skipped() != tt.wantSkipped {
+				t.Errorf("Skipped() = %d, want %d", j.Skipped(), tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	dir := t.TempDir()
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("x\n"))
+	w.Close()
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"app.log.1", gz.Bytes(), "gzip"},
+		{"app.log.2.xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, "xz"},
+		{"app.log.gz", []byte("INFO start\n"), ""},
+		{"empty.log", nil, ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := Compression(path); err != nil || got != tt.want {
+			t.Errorf("Compression(%s) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if got := CompressionByName("app.log.3.ZST"); got != "zstd" {
+		t.Errorf("CompressionByName() = %q", got)
+	}
+	if _, err := Compression(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Compression() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,117 @@
+// Package severity detects the level of a log line, such as ERROR or WARN,
+// from the words near its start.
+package severity
+
+// Level is a normalized log level
+type Level int
+
+const (
+	Unknown Level = iota
+	Trace
+	Debug
+	Info
+	Warn
+	Error
+	Fatal
+)
+
+// NumLevels is the number of levels including Unknown
+const NumLevels = int(Fatal) + 1
+
+// searchWindow is how far into a line the level is looked for
+const searchWindow = 128
+
+var names = [NumLevels]string{"UNKNOWN", "TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (l Level) String() string {
+	if l < 0 || int(l) >= NumLevels {
+		return names[Unknown]
+	}
+	return names[l]
+}
+
+// Detect returns the level of the first level word near the start of
+// line. Upper case words such as ERROR count anywhere; others only when
+// marked as a level, as in [error], level=warn or "Info:".
+func Detect(line []byte) Level {
+	if len(line) > searchWindow {
+		line = line[:searchWindow]
+	}
+
+	for i := 0; i < len(line); {
+		if !isLetter(line[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && isLetter(line[i]) {
+			i++
+		}
+		if level := lookup(line[start:i]); level != Unknown && marked(line, start, i) {
+			return level
+		}
+	}
+	return Unknown
+}
+
+// marked reports whether the word line[start:end] stands out as a level
+func marked(line []byte, start, end int) bool {
+	if isUpper(line[start:end]) {
+		return true
+	}
+	if start > 0 {
+		switch line[start-1] {
+		case '[', '<', '=', '"', '|':
+			return true
+		}
+	}
+	if end < len(line) {
+		switch line[end] {
+		case ']', '>', ':', '|':
+			return true
+		}
+	}
+	return false
+}
+
+func lookup(word []byte) Level {
+	if len(word) > 9 {
+		return Unknown
+	}
+	var lower [9]byte
+	for i, b := range word {
+		if b >= 'A' && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		lower[i] = b
+	}
+
+	switch string(lower[:len(word)]) {
+	case "trace", "finest", "finer":
+		return Trace
+	case "debug", "dbg", "fine":
+		return Debug
+	case "info", "notice":
+		return Info
+	case "warn", "warning":
+		return Warn
+	case "error", "err":
+		return Error
+	case "fatal", "crit", "critical", "panic", "emerg", "alert", "severe":
+		return Fatal
+	}
+	return Unknown
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isUpper(word []byte) bool {
+	for _, b := range word {
+		if b < 'A' || b > 'Z' {
+			return false
+		}
+	}
+	return len(word) > 1
+}
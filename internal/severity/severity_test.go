@@ -0,0 +1,32 @@
+package severity
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		line string
+		want Level
+	}{
+		{"2024-01-31 12:00:00 ERROR disk full", Error},
+		{"2024-01-31T12:00:00Z [warn] retrying", Warn},
+		{`time=2024-01-31T12:00:00Z level=info msg="started"`, Info},
+		{"Jan 31 12:00:00 host app: Debug: cache miss", Debug},
+		{"[31/Jan/2024:12:00:00 +0000] CRITICAL out of memory", Fatal},
+		{"2024-01-31 12:00:00 WARNING low disk space, ERROR follows", Warn},
+		{"user reported an error with the form", Unknown},
+		{"GET /info HTTP/1.1 200", Unknown},
+		{"", Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := Detect([]byte(tt.line)); got != tt.want {
+			t.Errorf("Detect(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	if Error.String() != "ERROR" || Level(42).String() != "UNKNOWN" {
+		t.Errorf("String() = %q, %q", Error, Level(42))
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+// MaxHistory is the number of runs kept in the history
+const MaxHistory = 100
+
+// Run modes
+const (
+	RunClean   = "clean"
+	RunBatch   = "batch"
+	RunMerge   = "merge"
+	RunRotated = "rotated"
+	RunInPlace = "in-place"
+)
+
+// Run is a processing run recorded in history.json
+type Run struct {
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Mode     string        `json:"mode"`
+	// Inputs in the order they were read; rotation sets oldest first
+	Inputs  []string `json:"inputs"`
+	Outputs []string `json:"outputs"`
+	Profile string   `json:"profile,omitempty"`
+
+	// Filters is the filter set the run used
+	Filters []*filter.Filter `json:"filters"`
+
+	DedupWindow      int  `json:"dedup_window,omitempty"`
+	DedupExact       bool `json:"dedup_exact,omitempty"`
+	PreserveEncoding bool `json:"preserve_encoding,omitempty"`
+	Labels           bool `json:"labels,omitempty"`
+	Backup           bool `json:"backup,omitempty"`
+
+	// Stats holds the counts only, see SetStats
+	Stats *cleaner.Stats `json:"stats,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// SetStats records the counts of stats, leaving out the histogram,
+// templates, suppressed shapes and removed lines
+func (r *Run) SetStats(stats *cleaner.Stats) {
+	if stats == nil {
+		r.Stats = nil
+		return
+	}
+	counts := *stats
+	counts.Histogram = nil
+	counts.Templates = nil
+	counts.Suppressed = nil
+	counts.Removed = nil
+	r.Stats = &counts
+}
+
+func (s *Storage) historyPath() string {
+	return filepath.Join(filepath.Dir(s.configPath), "history.json")
+}
+
+// LoadHistory returns the recorded runs, newest first
+func (s *Storage) LoadHistory() ([]Run, error) {
+	data, err := os.ReadFile(s.historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var runs []Run
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse history: %w", err)
+	}
+
+	return runs, nil
+}
+
+// AddRun records run as the newest, keeping the last MaxHistory runs
+func (s *Storage) AddRun(run Run) error {
+	runs, err := s.LoadHistory()
+	if err != nil {
+		return err
+	}
+	runs = append([]Run{run}, runs...)
+	if len(runs) > MaxHistory {
+		runs = runs[:MaxHistory]
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+
+	if err := os.WriteFile(s.historyPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	return nil
+}
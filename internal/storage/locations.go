@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"os"
+	"slices"
+)
+
+// MaxRecentFiles is the number of recently cleaned files kept
+const MaxRecentFiles = 10
+
+// AddRecentFile moves path to the front of the recent files
+func (c *Config) AddRecentFile(path string) {
+	c.RecentFiles = slices.DeleteFunc(c.RecentFiles, func(p string) bool { return p == path })
+	c.RecentFiles = append([]string{path}, c.RecentFiles...)
+	if len(c.RecentFiles) > MaxRecentFiles {
+		c.RecentFiles = c.RecentFiles[:MaxRecentFiles]
+	}
+}
+
+// ToggleBookmark bookmarks path, or removes its bookmark, and reports
+// whether it is bookmarked now
+func (c *Config) ToggleBookmark(path string) bool {
+	if slices.Contains(c.Bookmarks, path) {
+		c.Bookmarks = slices.DeleteFunc(c.Bookmarks, func(p string) bool { return p == path })
+		return false
+	}
+	c.Bookmarks = append(c.Bookmarks, path)
+	return true
+}
+
+// Forget removes path from the recent files and the bookmarks
+func (c *Config) Forget(path string) {
+	match := func(p string) bool { return p == path }
+	c.RecentFiles = slices.DeleteFunc(c.RecentFiles, match)
+	c.Bookmarks = slices.DeleteFunc(c.Bookmarks, match)
+}
+
+// Prune removes recent files and bookmarks that no longer exist and
+// reports whether any were removed
+func (c *Config) Prune() bool {
+	missing := func(path string) bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}
+	before := len(c.RecentFiles) + len(c.Bookmarks)
+	c.RecentFiles = slices.DeleteFunc(c.RecentFiles, missing)
+	c.Bookmarks = slices.DeleteFunc(c.Bookmarks, missing)
+	return len(c.RecentFiles)+len(c.Bookmarks) != before
+}
@@ -58,3 +58,52 @@ func (s *Storage) Save(filters []*filter.Filter) error {
 
 	return nil
 }
+
+// Config holds settings other than the filters, stored in config.json next
+// to filters.json
+type Config struct {
+	// OutputTemplate names cleaned files, see package output
+	OutputTemplate string `json:"output_template,omitempty"`
+	// OutputDir replaces the input's directory in output paths
+	OutputDir string `json:"output_dir,omitempty"`
+	// Profile names the filter set for the {profile} placeholder
+	Profile string `json:"profile,omitempty"`
+	// RecentFiles are the last cleaned inputs, newest first
+	RecentFiles []string `json:"recent_files,omitempty"`
+	// Bookmarks are saved log files and directories
+	Bookmarks []string `json:"bookmarks,omitempty"`
+}
+
+func (s *Storage) settingsPath() string {
+	return filepath.Join(filepath.Dir(s.configPath), "config.json")
+}
+
+func (s *Storage) LoadConfig() (*Config, error) {
+	data, err := os.ReadFile(s.settingsPath())
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func (s *Storage) SaveConfig(cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(s.settingsPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
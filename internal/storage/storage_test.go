@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
 
+	"github.com/sstreichan/logcleaner/internal/cleaner"
 	"github.com/sstreichan/logcleaner/internal/filter"
 )
 
@@ -48,3 +52,117 @@ func TestLoadNonExistent(t *testing.T) {
 		t.Errorf("Expected empty filter list, got %d", len(filters))
 	}
 }
+
+func TestConfigSaveAndLoad(t *testing.T) {
+	tempDir := t.TempDir()
+	s := &Storage{
+		configPath: filepath.Join(tempDir, "filters.json"),
+	}
+
+	cfg, err := s.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(*cfg, Config{}) {
+		t.Errorf("Expected an empty config, got %+v", *cfg)
+	}
+
+	cfg.OutputTemplate = "{name}-{date}{ext}"
+	cfg.OutputDir = "/tmp/cleaned"
+	if err := s.SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	loaded, err := s.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(*loaded, *cfg) {
+		t.Errorf("Expected %+v, got %+v", *cfg, *loaded)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	s := &Storage{
+		configPath: filepath.Join(tempDir, "filters.json"),
+	}
+
+	runs, err := s.LoadHistory()
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("LoadHistory() = %v, %v, want no runs", runs, err)
+	}
+
+	for i := 0; i < MaxHistory+2; i++ {
+		run := Run{
+			Mode:    RunClean,
+			Inputs:  []string{fmt.Sprintf("app%d.log", i)},
+			Filters: []*filter.Filter{{Name: "errors", Pattern: "^ERROR", Type: filter.TypeRemove}},
+		}
+		run.SetStats(&cleaner.Stats{TotalLines: i, Suppressed: map[string]int{"x": 1}})
+		if err := s.AddRun(run); err != nil {
+			t.Fatalf("AddRun() error = %v", err)
+		}
+	}
+
+	runs, err = s.LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(runs) != MaxHistory {
+		t.Fatalf("Expected %d runs, got %d", MaxHistory, len(runs))
+	}
+	newest := runs[0]
+	if newest.Inputs[0] != fmt.Sprintf("app%d.log", MaxHistory+1) || newest.Stats.TotalLines != MaxHistory+1 {
+		t.Errorf("Expected the newest run first, got %+v", newest)
+	}
+	if newest.Stats.Suppressed != nil {
+		t.Errorf("Expected only counts in the stats, got %+v", newest.Stats)
+	}
+	if len(newest.Filters) != 1 || !newest.Filters[0].Matches("ERROR x") {
+		t.Errorf("Expected the filter snapshot, got %+v", newest.Filters)
+	}
+}
+
+func TestConfigLocations(t *testing.T) {
+	tempDir := t.TempDir()
+	var paths []string
+	for i := 0; i < MaxRecentFiles+2; i++ {
+		path := filepath.Join(tempDir, fmt.Sprintf("app%d.log", i))
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	var cfg Config
+	for _, path := range paths {
+		cfg.AddRecentFile(path)
+	}
+	cfg.AddRecentFile(paths[5])
+	if len(cfg.RecentFiles) != MaxRecentFiles || cfg.RecentFiles[0] != paths[5] || cfg.RecentFiles[1] != paths[len(paths)-1] {
+		t.Errorf("Expected the newest %d files, most recent first, got %v", MaxRecentFiles, cfg.RecentFiles)
+	}
+
+	if !cfg.ToggleBookmark(tempDir) || !cfg.ToggleBookmark(paths[0]) || cfg.ToggleBookmark(paths[0]) {
+		t.Error("ToggleBookmark() should add and then remove a bookmark")
+	}
+	if !reflect.DeepEqual(cfg.Bookmarks, []string{tempDir}) {
+		t.Errorf("Expected the directory bookmark, got %v", cfg.Bookmarks)
+	}
+
+	if cfg.Prune() {
+		t.Error("Prune() removed existing entries")
+	}
+	if err := os.Remove(paths[5]); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Prune() || slices.Contains(cfg.RecentFiles, paths[5]) {
+		t.Errorf("Prune() kept a deleted file: %v", cfg.RecentFiles)
+	}
+
+	cfg.Forget(tempDir)
+	if len(cfg.Bookmarks) != 0 {
+		t.Errorf("Forget() kept the bookmark: %v", cfg.Bookmarks)
+	}
+}
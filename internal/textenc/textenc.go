@@ -0,0 +1,164 @@
+package textenc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+type Encoding string
+
+const (
+	UTF8        Encoding = "utf-8"
+	UTF16LE     Encoding = "utf-16le"
+	UTF16BE     Encoding = "utf-16be"
+	Latin1      Encoding = "iso-8859-1"
+	Windows1252 Encoding = "windows-1252"
+)
+
+// sampleSize is how much of a file Detect looks at
+const sampleSize = 8 * 1024
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Detection describes the encoding of a file as guessed from its beginning
+type Detection struct {
+	Encoding Encoding
+	BOM      bool
+	Binary   bool
+}
+
+// NeedsTranscoding reports whether the content differs from plain UTF-8
+func (d Detection) NeedsTranscoding() bool {
+	return d.Encoding != UTF8 || d.BOM
+}
+
+func (d Detection) String() string {
+	s := string(d.Encoding)
+	if d.BOM {
+		s += " (BOM)"
+	}
+	return s
+}
+
+// Parse returns the encoding for a user supplied name
+func Parse(name string) (Encoding, error) {
+	switch Encoding(name) {
+	case UTF8, UTF16LE, UTF16BE, Latin1, Windows1252:
+		return Encoding(name), nil
+	case "latin1", "latin-1":
+		return Latin1, nil
+	case "cp1252":
+		return Windows1252, nil
+	}
+	return "", fmt.Errorf("unsupported encoding: %s", name)
+}
+
+// DetectFile runs Detect on the beginning of the file at path
+func DetectFile(path string) (Detection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return DetectReader(file)
+}
+
+// DetectReader runs Detect on the beginning of what r produces
+func DetectReader(r io.Reader) (Detection, error) {
+	sample := make([]byte, sampleSize)
+	n, err := io.ReadFull(r, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Detection{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	sample = sample[:n]
+	if n == sampleSize {
+		sample = trimPartialRune(sample)
+	}
+	return Detect(sample), nil
+}
+
+// Detect guesses the encoding of sample from a byte order mark, the
+// distribution of NUL bytes and UTF-8 validity
+func Detect(sample []byte) Detection {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return Detection{Encoding: UTF8, BOM: true}
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return Detection{Encoding: UTF16LE, BOM: true}
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return Detection{Encoding: UTF16BE, BOM: true}
+	}
+
+	// ASCII text in UTF-16 has a NUL in every other byte
+	var evenNUL, oddNUL, control int
+	for i, b := range sample {
+		switch {
+		case b == 0 && i%2 == 0:
+			evenNUL++
+		case b == 0:
+			oddNUL++
+		case b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\v' && b != 0x1B:
+			control++
+		}
+	}
+
+	pairs := len(sample) / 2
+	if pairs > 0 {
+		if oddNUL*3 > pairs && evenNUL*20 <= pairs {
+			return Detection{Encoding: UTF16LE}
+		}
+		if evenNUL*3 > pairs && oddNUL*20 <= pairs {
+			return Detection{Encoding: UTF16BE}
+		}
+	}
+
+	if evenNUL+oddNUL > 0 || control > len(sample)/10 {
+		return Detection{Encoding: UTF8, Binary: true}
+	}
+
+	// A single valid multi-byte sequence makes UTF-8 with some stray bytes
+	// more likely than a single-byte encoding
+	if utf8.Valid(sample) || hasMultiByteRune(sample) {
+		return Detection{Encoding: UTF8}
+	}
+
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F {
+			return Detection{Encoding: Windows1252}
+		}
+	}
+	return Detection{Encoding: Latin1}
+}
+
+func hasMultiByteRune(sample []byte) bool {
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r != utf8.RuneError && size > 1 {
+			return true
+		}
+		sample = sample[size:]
+	}
+	return false
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off by the end of a sample
+func trimPartialRune(sample []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				return sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	return sample
+}
@@ -0,0 +1,97 @@
+package textenc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16LE(s string, withBOM bool) []byte {
+	var out []byte
+	if withBOM {
+		out = append(out, bomUTF16LE...)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample []byte
+		want   Detection
+	}{
+		{"plain utf-8", []byte("INFO: Größe ok\n"), Detection{Encoding: UTF8}},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "INFO\n"...), Detection{Encoding: UTF8, BOM: true}},
+		{"utf-16le bom", encodeUTF16LE("INFO: start\r\n", true), Detection{Encoding: UTF16LE, BOM: true}},
+		{"utf-16le without bom", encodeUTF16LE("INFO: start\r\n", false), Detection{Encoding: UTF16LE}},
+		{"latin-1", []byte("INFO: Gr\xf6\xdfe\n"), Detection{Encoding: Latin1}},
+		{"utf-8 with stray bytes", []byte("INFO: Größe \xff\n"), Detection{Encoding: UTF8}},
+		{"windows-1252", []byte("INFO: \x93quoted\x94 \x80\n"), Detection{Encoding: Windows1252}},
+		{"binary", []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x03\x00\x3e\x00"), Detection{Encoding: UTF8, Binary: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.sample); got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	text := "INFO: Größe €5 “quoted” 😀\r\nERROR: done\n"
+
+	tests := []struct {
+		name string
+		d    Detection
+		raw  []byte
+		want string
+	}{
+		{"utf-16le", Detection{Encoding: UTF16LE, BOM: true}, encodeUTF16LE(text, true), text},
+		{"latin-1", Detection{Encoding: Latin1}, []byte("Gr\xf6\xdfe\r\n"), "Größe\r\n"},
+		{"windows-1252", Detection{Encoding: Windows1252}, []byte("\x93\x80\x94\n"), "“€”\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A one byte reader exercises sequences split across reads
+			decoded, err := io.ReadAll(NewDecoder(&oneByteReader{bytes.NewReader(tt.raw)}, tt.d))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded) != tt.want {
+				t.Errorf("Decoded %q, want %q", decoded, tt.want)
+			}
+
+			var encoded bytes.Buffer
+			enc := NewEncoder(&encoded, tt.d)
+			for _, b := range decoded {
+				if _, err := enc.Write([]byte{b}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded.Bytes(), tt.raw) {
+				t.Errorf("Encoded %x, want %x", encoded.Bytes(), tt.raw)
+			}
+		})
+	}
+}
+
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
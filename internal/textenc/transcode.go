@@ -0,0 +1,262 @@
+package textenc
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// windows1252 maps the bytes 0x80-0x9F to Unicode; undefined bytes keep
+// their Latin-1 code point like Windows does
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+var windows1252Reverse = func() map[rune]byte {
+	m := make(map[rune]byte, len(windows1252))
+	for i, r := range windows1252 {
+		m[r] = byte(0x80 + i)
+	}
+	return m
+}()
+
+func bom(enc Encoding) []byte {
+	switch enc {
+	case UTF8:
+		return bomUTF8
+	case UTF16LE:
+		return bomUTF16LE
+	case UTF16BE:
+		return bomUTF16BE
+	}
+	return nil
+}
+
+type decoder struct {
+	src     io.Reader
+	enc     Encoding
+	skipBOM []byte
+	buf     []byte
+	in      []byte
+	out     []byte
+	pos     int
+	err     error
+}
+
+// NewDecoder returns a reader producing UTF-8 from r, which is encoded as
+// described by d. A byte order mark is removed.
+func NewDecoder(r io.Reader, d Detection) io.Reader {
+	if !d.NeedsTranscoding() {
+		return r
+	}
+
+	dec := &decoder{src: r, enc: d.Encoding, buf: make([]byte, 32*1024)}
+	if d.BOM {
+		dec.skipBOM = bom(d.Encoding)
+	}
+	return dec
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for d.pos == len(d.out) {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		n, err := d.src.Read(d.buf)
+		d.in = append(d.in, d.buf[:n]...)
+		final := err != nil
+
+		if d.skipBOM != nil {
+			if len(d.in) < len(d.skipBOM) && !final {
+				continue
+			}
+			d.in = bytes.TrimPrefix(d.in, d.skipBOM)
+			d.skipBOM = nil
+		}
+
+		d.out, d.pos = d.decode(d.out[:0], final), 0
+		d.err = err
+	}
+
+	n := copy(p, d.out[d.pos:])
+	d.pos += n
+	return n, nil
+}
+
+// decode converts as much of d.in as possible, keeping incomplete
+// sequences for the next call unless final is set
+func (d *decoder) decode(out []byte, final bool) []byte {
+	in := d.in
+	switch d.enc {
+	case UTF16LE, UTF16BE:
+		for len(in) >= 2 {
+			u := d.unit(in)
+			if utf16.IsSurrogate(rune(u)) && u < 0xDC00 {
+				if len(in) < 4 {
+					if !final {
+						break
+					}
+					out = utf8.AppendRune(out, utf8.RuneError)
+					in = in[2:]
+					continue
+				}
+				if r := utf16.DecodeRune(rune(u), rune(d.unit(in[2:]))); r != utf8.RuneError {
+					out = utf8.AppendRune(out, r)
+					in = in[4:]
+					continue
+				}
+				out = utf8.AppendRune(out, utf8.RuneError)
+				in = in[2:]
+				continue
+			}
+			if utf16.IsSurrogate(rune(u)) {
+				out = utf8.AppendRune(out, utf8.RuneError)
+			} else {
+				out = utf8.AppendRune(out, rune(u))
+			}
+			in = in[2:]
+		}
+		if final && len(in) > 0 {
+			out = utf8.AppendRune(out, utf8.RuneError)
+			in = in[len(in):]
+		}
+
+	case Latin1, Windows1252:
+		for _, b := range in {
+			if b >= 0x80 && b <= 0x9F && d.enc == Windows1252 {
+				out = utf8.AppendRune(out, windows1252[b-0x80])
+			} else {
+				out = utf8.AppendRune(out, rune(b))
+			}
+		}
+		in = in[len(in):]
+
+	default:
+		out = append(out, in...)
+		in = in[len(in):]
+	}
+
+	d.in = append(d.in[:0], in...)
+	return out
+}
+
+func (d *decoder) unit(b []byte) uint16 {
+	if d.enc == UTF16BE {
+		return uint16(b[0])<<8 | uint16(b[1])
+	}
+	return uint16(b[1])<<8 | uint16(b[0])
+}
+
+type encoder struct {
+	dst   io.Writer
+	enc   Encoding
+	bom   []byte
+	carry []byte
+	buf   []byte
+}
+
+// NewEncoder returns a writer converting UTF-8 written to it into the
+// encoding described by d, starting with a byte order mark if d has one.
+// Close must be called to flush an incomplete trailing sequence.
+func NewEncoder(w io.Writer, d Detection) io.WriteCloser {
+	if !d.NeedsTranscoding() {
+		return nopCloser{w}
+	}
+
+	enc := &encoder{dst: w, enc: d.Encoding}
+	if d.BOM {
+		enc.bom = bom(d.Encoding)
+	}
+	return enc
+}
+
+func (e *encoder) Write(p []byte) (int, error) {
+	data := p
+	if len(e.carry) > 0 {
+		data = append(e.carry, p...)
+		e.carry = nil
+	}
+
+	// Keep a UTF-8 sequence cut off at the end for the next write
+	if cut := len(data) - len(trimPartialRune(data)); cut > 0 {
+		e.carry = append([]byte(nil), data[len(data)-cut:]...)
+		data = data[:len(data)-cut]
+	}
+
+	if err := e.write(data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (e *encoder) Close() error {
+	carry := e.carry
+	e.carry = nil
+	return e.write(carry)
+}
+
+func (e *encoder) write(data []byte) error {
+	out := e.buf[:0]
+	if e.bom != nil {
+		out = append(out, e.bom...)
+		e.bom = nil
+	}
+
+	if e.enc == UTF8 {
+		out = append(out, data...)
+	}
+	for e.enc != UTF8 && len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		out = e.appendRune(out, r)
+	}
+
+	e.buf = out
+	_, err := e.dst.Write(out)
+	return err
+}
+
+func (e *encoder) appendRune(out []byte, r rune) []byte {
+	switch e.enc {
+	case UTF16LE, UTF16BE:
+		if r >= 0x10000 {
+			r1, r2 := utf16.EncodeRune(r)
+			return e.appendUnit(e.appendUnit(out, uint16(r1)), uint16(r2))
+		}
+		return e.appendUnit(out, uint16(r))
+
+	case Latin1, Windows1252:
+		if e.enc == Windows1252 {
+			if b, ok := windows1252Reverse[r]; ok {
+				return append(out, b)
+			}
+			if r >= 0x80 && r <= 0x9F {
+				return append(out, '?')
+			}
+		}
+		if r > 0xFF {
+			return append(out, '?')
+		}
+		return append(out, byte(r))
+	}
+
+	return utf8.AppendRune(out, r)
+}
+
+func (e *encoder) appendUnit(out []byte, u uint16) []byte {
+	if e.enc == UTF16BE {
+		return append(out, byte(u>>8), byte(u))
+	}
+	return append(out, byte(u), byte(u>>8))
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -4,26 +4,41 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/sstreichan/logcleaner/internal/discover"
 )
 
 type Autocomplete struct {
-	cache        map[string][]string
+	// cache holds directory listings by cleaned path, see listing.go
+	cache        map[string]*dirListing
 	lastMatches  []string
 	lastInput    string
 	currentIndex int
+	byTime       bool   // sort matches newest first instead of directories first
+	pending      string // input waiting for its directory to be read
 }
 
 func NewAutocomplete() *Autocomplete {
 	return &Autocomplete{
-		cache:        make(map[string][]string),
+		cache:        make(map[string]*dirListing),
 		lastMatches:  []string{},
 		lastInput:    "",
 		currentIndex: 0,
 	}
 }
 
-// Complete returns the autocompleted path based on the current input
+// Complete returns the autocompleted path based on the current input,
+// reading its directory first unless the cached listing is current
 func (a *Autocomplete) Complete(input string) string {
+	if input != "" {
+		dir, _ := a.parseInput(input)
+		a.load(dir)
+	}
+	return a.complete(input)
+}
+
+// complete completes input from the cached listing of its directory
+func (a *Autocomplete) complete(input string) string {
 	// Handle empty input - start with current directory
 	if input == "" {
 		cwd, err := os.Getwd()
@@ -43,6 +58,14 @@ func (a *Autocomplete) Complete(input string) string {
 		return input
 	}
 
+	// A glob stays as typed, its matches are only listed
+	if discover.IsPattern(base) {
+		a.lastMatches = matches
+		a.lastInput = input
+		a.currentIndex = 0
+		return input
+	}
+
 	if len(matches) == 1 {
 		// Single match - return it and reset
 		a.lastMatches = matches
@@ -57,13 +80,13 @@ func (a *Autocomplete) Complete(input string) string {
 		a.lastMatches = matches
 		a.lastInput = input
 		a.currentIndex = 0
-		
+
 		// Try common prefix first
 		commonPrefix := a.findCommonPrefix(matches)
 		if commonPrefix != input {
 			return commonPrefix
 		}
-		
+
 		// No common prefix, return first match
 		return matches[0]
 	}
@@ -77,6 +100,9 @@ func (a *Autocomplete) Complete(input string) string {
 func (a *Autocomplete) parseInput(input string) (string, string) {
 	var dir, base string
 
+	// Expand environment variables and ~ or ~user
+	input, _ = discover.ExpandPath(input)
+
 	// Handle trailing separator (user is in a directory)
 	if strings.HasSuffix(input, string(filepath.Separator)) {
 		dir = input
@@ -86,18 +112,6 @@ func (a *Autocomplete) parseInput(input string) (string, string) {
 		base = filepath.Base(input)
 	}
 
-	// Expand ~ to home directory
-	if strings.HasPrefix(dir, "~") {
-		home, err := os.UserHomeDir()
-		if err == nil {
-			if dir == "~" {
-				dir = home
-			} else {
-				dir = strings.Replace(dir, "~", home, 1)
-			}
-		}
-	}
-
 	// Handle relative paths
 	if dir == "." || dir == "" {
 		cwd, err := os.Getwd()
@@ -111,32 +125,39 @@ func (a *Autocomplete) parseInput(input string) (string, string) {
 	return dir, base
 }
 
-// findMatches returns all filesystem entries matching the base pattern in dir
+// findMatches returns the cached entries of dir matching the base pattern,
+// sorted by sortEntries
 func (a *Autocomplete) findMatches(dir, base string) []string {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
+	listing := a.cache[filepath.Clean(dir)]
+	if listing == nil {
 		return []string{}
 	}
 
-	var matches []string
-	for _, entry := range entries {
+	var entries []dirEntry
+	for _, entry := range listing.entries {
 		// Skip hidden files unless user explicitly typed a dot
-		if !strings.HasPrefix(base, ".") && strings.HasPrefix(entry.Name(), ".") {
+		if !strings.HasPrefix(base, ".") && strings.HasPrefix(entry.name, ".") {
 			continue
 		}
 
-		// Check if entry matches the base
-		if base == "" || strings.HasPrefix(entry.Name(), base) {
-			fullPath := filepath.Join(dir, entry.Name())
-			
-			// Add trailing separator for directories
-			if entry.IsDir() {
-				fullPath += string(filepath.Separator)
-			}
-			
-			matches = append(matches, fullPath)
+		// Check if entry matches the base, as a glob if it is one
+		if base == "" || matchBase(base, entry.name) {
+			entries = append(entries, entry)
 		}
 	}
+	a.sortEntries(entries)
+
+	matches := []string{}
+	for _, entry := range entries {
+		fullPath := filepath.Join(dir, entry.name)
+
+		// Add trailing separator for directories
+		if entry.dir {
+			fullPath += string(filepath.Separator)
+		}
+
+		matches = append(matches, fullPath)
+	}
 
 	return matches
 }
@@ -176,6 +197,17 @@ func (a *Autocomplete) Reset() {
 	a.lastMatches = []string{}
 	a.lastInput = ""
 	a.currentIndex = 0
+	a.pending = ""
+}
+
+// matchBase reports whether name starts with base or, if base is a glob,
+// matches it
+func matchBase(base, name string) bool {
+	if discover.IsPattern(base) {
+		ok, _ := filepath.Match(base, name)
+		return ok
+	}
+	return strings.HasPrefix(name, base)
 }
 
 // commonPrefix returns the common prefix of two strings
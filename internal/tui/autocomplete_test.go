@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func TestComplete_EmptyInput(t *testing.T) {
@@ -109,3 +112,51 @@ func TestCommonPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestComplete_ExpandsVariables(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTree(t, tempDir, "api/app.log", "api/app.log.1", "api/access.log")
+	t.Setenv("LOG_ROOT", tempDir)
+
+	a := NewAutocomplete()
+	if result := a.Complete("$LOG_ROOT/api/acc"); result != filepath.Join(tempDir, "api", "access.log") {
+		t.Errorf("Complete() = %s", result)
+	}
+
+	// A glob is kept as typed and its matches listed
+	input := "${LOG_ROOT}/api/a*.log"
+	if result := a.Complete(input); result != input {
+		t.Errorf("Complete(%s) = %s", input, result)
+	}
+	if matches := a.GetLastMatches(); len(matches) != 2 {
+		t.Errorf("glob matches = %v", matches)
+	}
+}
+
+func TestFileSelect_ExpandsInput(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTree(t, tempDir, "api/app.log", "api/access.log")
+	t.Setenv("LOG_ROOT", tempDir)
+
+	m := Model{fileInput: textinput.New(), autocomplete: NewAutocomplete(), locationCursor: -1}
+	m.fileInput.SetValue("$LOG_ROOT/api/*.log")
+	m.inspectInput()
+	view := m.fileSelectView()
+	if len(m.inputFiles) != 2 || !strings.Contains(view, "✓ 2 files match") || !strings.Contains(view, "= "+filepath.Join(tempDir, "api")) {
+		t.Errorf("inputFiles = %v, view =\n%s", m.inputFiles, view)
+	}
+
+	m.fileInput.SetValue("$NO_SUCH_LOG_VAR/app.log")
+	m.inspectInput()
+	if view := m.fileSelectView(); !strings.Contains(view, "NO_SUCH_LOG_VAR is not set") {
+		t.Errorf("view =\n%s", view)
+	}
+
+	m.fileInput.SetValue("$LOG_ROOT/api/app.log")
+	m.inspectInput()
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if m.screen != screenFilterManage || m.filePath != filepath.Join(tempDir, "api", "app.log") {
+		t.Errorf("screen = %v, filePath = %q", m.screen, m.filePath)
+	}
+}
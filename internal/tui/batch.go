@@ -0,0 +1,243 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/manifest"
+)
+
+const batchMaxShown = 15
+
+type batchMsg struct {
+	result *cleaner.BatchResult
+}
+
+func (m Model) updateBatchReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+
+	case "esc":
+		m.screen = screenFileSelect
+		m.batchFiles = nil
+		m.mergeFiles, m.labelLines = false, false
+		return m, nil
+
+	case "m":
+		m.mergeFiles = !m.mergeFiles
+
+	case "l":
+		if m.mergeFiles {
+			m.labelLines = !m.labelLines
+		}
+
+	case "enter":
+		m.screen = screenFilterManage
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) processBatch() tea.Cmd {
+	return func() tea.Msg {
+		opts := m.cleanerOptions()
+		filters := m.activeFilters()
+		c := cleaner.NewWithOptions(filters, opts)
+		recorded := manifest.NewOptions(opts)
+
+		if err := createOutputDirs(m.outputPaths()); err != nil {
+			return processingMsg{err: err}
+		}
+
+		if m.mergeFiles {
+			outputPath := m.outputPath()
+			merge := mergeOptions(m.batchFiles, m.labelLines)
+			recorded.Labels = merge.Labels
+			stats, err := recordRun(manifest.ModeMerge, m.batchFiles, outputPath, filters, recorded, func() (*cleaner.Stats, error) {
+				return cleanMerged(c, m.batchFiles, outputPath, merge)
+			})
+			return processingMsg{stats: stats, err: err}
+		}
+
+		manifests := make([]*manifest.Manifest, len(m.batchFiles))
+		for i, input := range m.batchFiles {
+			record, err := manifest.Begin(manifest.ModeClean, []string{input}, filters, recorded)
+			if err != nil {
+				return processingMsg{err: err}
+			}
+			manifests[i] = record
+		}
+
+		result := c.CleanFiles(m.batchFiles, func(input string) string {
+			return m.namer.Path(input)
+		}, nil)
+		for i := range result.Files {
+			if res := &result.Files[i]; res.Err == nil {
+				res.Err = manifests[i].Finish(res.OutputPath, res.Stats)
+			}
+		}
+
+		return batchMsg{result: result}
+	}
+}
+
+// mergeOptions labels each line with the name of its file if label is set
+func mergeOptions(files []string, label bool) cleaner.MergeOptions {
+	var opts cleaner.MergeOptions
+	if label {
+		for _, file := range files {
+			opts.Labels = append(opts.Labels, filepath.Base(file))
+		}
+	}
+	return opts
+}
+
+// cleanMerged interleaves files by timestamp into a single cleaned file
+func cleanMerged(c *cleaner.Cleaner, files []string, outputPath string, opts cleaner.MergeOptions) (*cleaner.Stats, error) {
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outFile.Close()
+
+	return c.CleanMerged(files, outFile, opts, nil)
+}
+
+func (m Model) batchReviewView() string {
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render("📂 Batch Processing"))
+	sb.WriteString("\n\n")
+	sb.WriteString(infoStyle.Render(fmt.Sprintf("%s matches %d files:", m.fileInput.Value(), len(m.batchFiles))))
+	sb.WriteString("\n\n")
+
+	var totalSize int64
+	for i, path := range m.batchFiles {
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+		totalSize += size
+
+		if i < batchMaxShown {
+			sb.WriteString(itemStyle.Render(fmt.Sprintf("  %-40s %10s", filepath.Base(path), formatSize(size))))
+			sb.WriteString("\n")
+		}
+	}
+	if len(m.batchFiles) > batchMaxShown {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.batchFiles)-batchMaxShown)))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	if m.mergeFiles {
+		labels := "off"
+		if m.labelLines {
+			labels = "on"
+		}
+		sb.WriteString(subtitleStyle.Render(fmt.Sprintf("Total: %s, merged by timestamp into %s (source labels: %s)", formatSize(totalSize), m.outputPath(), labels)))
+	} else {
+		sb.WriteString(subtitleStyle.Render(fmt.Sprintf("Total: %s, each written as in %s", formatSize(totalSize), m.namer.Path(m.batchFiles[0]))))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(helpStyle.Render("Enter: choose filters | m: merge by timestamp | l: source labels | Esc: back | Ctrl+C: quit"))
+
+	return sb.String()
+}
+
+func (m Model) batchResultsView() string {
+	var sb strings.Builder
+
+	failed := m.batch.Failed()
+	if len(failed) == 0 {
+		sb.WriteString(titleStyle.Render("✅ Complete"))
+	} else {
+		sb.WriteString(titleStyle.Render(fmt.Sprintf("⚠️  Complete with %d errors", len(failed))))
+	}
+	sb.WriteString("\n\n")
+
+	for i, res := range m.batch.Files {
+		if i >= batchMaxShown {
+			sb.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.batch.Files)-batchMaxShown)))
+			sb.WriteString("\n")
+			break
+		}
+
+		name := filepath.Base(res.InputPath)
+		if res.Err != nil {
+			sb.WriteString(errorStyle.Render(fmt.Sprintf("  ✗ %-36s failed", name)))
+		} else {
+			sb.WriteString(itemStyle.Render(fmt.Sprintf("  ✓ %-36s %8d lines, %8d filtered", name, res.Stats.TotalLines, res.Stats.FilteredLines)))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	statsBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 2).
+		Width(50)
+
+	total := m.batch.Total
+	sb.WriteString(statsBox.Render(fmt.Sprintf(
+		"📊 Total (%d of %d files)\n\n"+
+			"Total Lines:     %d\n"+
+			"Filtered Lines:  %d\n"+
+			"Remaining Lines: %d\n"+
+			"Bytes Processed: %.2f MB",
+		len(m.batch.Files)-len(failed),
+		len(m.batch.Files),
+		total.TotalLines,
+		total.FilteredLines,
+		total.RemainingLines(),
+		float64(total.BytesRead)/(1024*1024),
+	) + repeatsLine(total.DuplicateLines)))
+
+	if len(failed) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(subtitleStyle.Render("Errors:"))
+		sb.WriteString("\n")
+		for _, res := range failed {
+			sb.WriteString(errorStyle.Render(fmt.Sprintf("  %s: %v", res.InputPath, res.Err)))
+			sb.WriteString("\n")
+		}
+	}
+
+	if line := m.reportLine(); line != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(line)
+	}
+
+	sb.WriteString("\n\n")
+	sb.WriteString(helpStyle.Render("m/h: Markdown/HTML report | Enter: process another file | Ctrl+C: quit"))
+
+	return sb.String()
+}
+
+func repeatsLine(repeats int) string {
+	if repeats == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nRepeats:         %d collapsed", repeats)
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1024*1024*1024))
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
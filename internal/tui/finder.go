@@ -0,0 +1,479 @@
+package tui
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Limits of the walk behind the fuzzy finder
+const (
+	finderMaxDepth   = 8
+	finderMaxFiles   = 50000
+	finderMaxEntries = 10000 // directories with more entries are skipped
+	finderShown      = 10
+)
+
+// finderSkipDirs are never descended into: dependency and cache trees by
+// name, virtual file systems by path
+var finderSkipDirs = map[string]bool{
+	"node_modules": true,
+	"__pycache__":  true,
+	"/proc":        true,
+	"/sys":         true,
+	"/dev":         true,
+}
+
+// Fuzzy scoring, loosely following fzf: every matched character scores,
+// gaps cost, and characters at the start of a path segment or word, or
+// continuing a run of matches, earn a bonus
+const (
+	scoreMatch       = 16
+	scoreGapStart    = -3
+	scoreGapExtend   = -1
+	bonusSeparator   = 9
+	bonusBoundary    = 8
+	bonusCamel       = 7
+	bonusConsecutive = 4
+)
+
+// fileFinder is the fuzzy finder over the files under root, with the path
+// input as query
+type fileFinder struct {
+	root      string
+	scanning  bool          // the walk has not finished yet
+	stop      chan struct{} // closed when the finder is closed
+	files     []string      // relative to root, shallowest first
+	truncated bool
+	skipped   int // directories left out for their size
+	err       error
+
+	previous string // path input before the finder was opened
+	matches  []fuzzyMatch
+	cursor   int
+}
+
+// finderMsg delivers the files found by the walk of a finder
+type finderMsg struct {
+	finder    *fileFinder
+	files     []string
+	truncated bool
+	skipped   int
+	err       error
+}
+
+// fuzzyMatch is a file matching the query, with the rune positions of the
+// matched characters
+type fuzzyMatch struct {
+	path      string
+	score     int
+	positions []int
+}
+
+// walkFiles lists the files under root breadth first, so the depth and
+// file limits cut off the deepest files. Hidden directories, those in
+// finderSkipDirs and those with more than finderMaxEntries entries are
+// skipped. The walk ends early once stop is closed.
+func walkFiles(root string, stop <-chan struct{}) (files []string, truncated bool, skipped int, err error) {
+	type dir struct {
+		rel   string
+		depth int
+	}
+	queue := []dir{{rel: "", depth: 0}}
+
+	for len(queue) > 0 {
+		select {
+		case <-stop:
+			return files, true, skipped, nil
+		default:
+		}
+
+		d := queue[0]
+		queue = queue[1:]
+
+		entries, huge, readErr := readDirLimited(filepath.Join(root, d.rel))
+		if readErr != nil {
+			if d.rel == "" {
+				return nil, false, 0, fmt.Errorf("failed to read %s: %w", root, readErr)
+			}
+			continue
+		}
+		if huge {
+			skipped++
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			rel := filepath.Join(d.rel, name)
+			if entry.IsDir() {
+				if strings.HasPrefix(name, ".") || finderSkipDirs[name] || finderSkipDirs[filepath.Join(root, rel)] {
+					continue
+				}
+				if d.depth+1 < finderMaxDepth {
+					queue = append(queue, dir{rel: rel, depth: d.depth + 1})
+				}
+				continue
+			}
+			if !isRegularFile(filepath.Join(root, rel), entry) {
+				continue
+			}
+			if len(files) == finderMaxFiles {
+				return files, true, skipped, nil
+			}
+			files = append(files, rel)
+		}
+	}
+
+	return files, false, skipped, nil
+}
+
+// readDirLimited reads the entries of a directory sorted by name and
+// reports whether it has more than finderMaxEntries of them
+func readDirLimited(path string) ([]fs.DirEntry, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	entries, err := f.ReadDir(finderMaxEntries + 1)
+	if err != nil && len(entries) == 0 {
+		return nil, false, err
+	}
+	if len(entries) > finderMaxEntries {
+		return nil, true, nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, false, nil
+}
+
+// isRegularFile reports whether entry is a regular file or a symlink to one
+func isRegularFile(path string, entry fs.DirEntry) bool {
+	if entry.Type().IsRegular() {
+		return true
+	}
+	if entry.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// fuzzyScore matches each space separated term of query against path, its
+// characters in order but not necessarily adjacent. Matching ignores case
+// unless the term has upper case letters. It returns the summed score and
+// the matched positions, or false if a term does not match.
+func fuzzyScore(path, query string) (int, []int, bool) {
+	runes := []rune(path)
+	total := 0
+	var positions []int
+
+	for _, term := range strings.Fields(query) {
+		score, matched, ok := scoreTerm(runes, []rune(term))
+		if !ok {
+			return 0, nil, false
+		}
+		total += score
+		positions = append(positions, matched...)
+	}
+
+	// Terms may match the same characters
+	slices.Sort(positions)
+	return total, slices.Compact(positions), true
+}
+
+// scoreTerm scores the shortest window of path holding the characters of
+// term: the first match found going forward, narrowed by going back from
+// its end
+func scoreTerm(path, term []rune) (int, []int, bool) {
+	caseSensitive := false
+	for _, r := range term {
+		if unicode.IsUpper(r) {
+			caseSensitive = true
+			break
+		}
+	}
+	equal := func(p, t rune) bool {
+		if caseSensitive {
+			return p == t
+		}
+		return unicode.ToLower(p) == t
+	}
+
+	end, ti := -1, 0
+	for i := 0; i < len(path) && ti < len(term); i++ {
+		if equal(path[i], term[ti]) {
+			ti++
+			end = i
+		}
+	}
+	if ti < len(term) {
+		return 0, nil, false
+	}
+
+	start := end
+	for i, ti := end, len(term)-1; ti >= 0; i-- {
+		if equal(path[i], term[ti]) {
+			start = i
+			ti--
+		}
+	}
+
+	score, run, firstBonus, inGap := 0, 0, 0, false
+	positions := make([]int, 0, len(term))
+	ti = 0
+	for i := start; i <= end; i++ {
+		if ti == len(term) || !equal(path[i], term[ti]) {
+			if inGap {
+				score += scoreGapExtend
+			} else {
+				score += scoreGapStart
+			}
+			inGap, run = true, 0
+			continue
+		}
+
+		bonus := charBonus(path, i)
+		if run == 0 {
+			firstBonus = bonus
+		} else {
+			// A run keeps the bonus of the boundary it started at
+			bonus = max(bonus, firstBonus, bonusConsecutive)
+		}
+		if ti == 0 {
+			bonus *= 2
+		}
+		score += scoreMatch + bonus
+		positions = append(positions, i)
+		inGap = false
+		run++
+		ti++
+	}
+
+	return score, positions, true
+}
+
+// charBonus rates position i of path as the start of a segment or word
+func charBonus(path []rune, i int) int {
+	if i == 0 {
+		return bonusSeparator
+	}
+	prev, cur := path[i-1], path[i]
+	switch {
+	case prev == '/' || prev == filepath.Separator:
+		return bonusSeparator
+	case strings.ContainsRune("-_. ", prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && (unicode.IsLetter(cur) || unicode.IsDigit(cur)):
+		return bonusBoundary
+	}
+	return 0
+}
+
+// rankFiles returns the files matching query, best first; ties go to the
+// shorter path. An empty query matches all files in walk order.
+func rankFiles(files []string, query string) []fuzzyMatch {
+	matches := make([]fuzzyMatch, 0, len(files))
+	for _, path := range files {
+		score, positions, ok := fuzzyScore(path, query)
+		if ok {
+			matches = append(matches, fuzzyMatch{path: path, score: score, positions: positions})
+		}
+	}
+	if strings.TrimSpace(query) == "" {
+		return matches
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(matches[i].path) < len(matches[j].path)
+	})
+	return matches
+}
+
+// finderRoot returns the directory to search: the entered directory, the
+// directory of the entered path, or the working directory
+func (m Model) finderRoot() string {
+	input := m.inputPath()
+	root := input
+	if info, err := os.Stat(input); input == "" || err != nil || !info.IsDir() {
+		root, _ = m.autocomplete.parseInput(input)
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return root
+}
+
+// openFinder switches the path input to a fuzzy query over the files under
+// the entered directory, which are walked in the background
+func (m Model) openFinder() (tea.Model, tea.Cmd) {
+	f := &fileFinder{
+		root:     m.finderRoot(),
+		scanning: true,
+		stop:     make(chan struct{}),
+		previous: m.fileInput.Value(),
+	}
+
+	m.finder = f
+	m.fileInput.SetValue("")
+	m.autocomplete.Reset()
+	m.inspectInput()
+	m.locationCursor = -1
+	return m, scanFiles(f)
+}
+
+// scanFiles walks the tree of a finder
+func scanFiles(f *fileFinder) tea.Cmd {
+	root, stop := f.root, f.stop
+	return func() tea.Msg {
+		msg := finderMsg{finder: f}
+		msg.files, msg.truncated, msg.skipped, msg.err = walkFiles(root, stop)
+		return msg
+	}
+}
+
+// updateScan ranks the files found by the walk, unless the finder was
+// closed or reopened since
+func (m Model) updateScan(msg finderMsg) (tea.Model, tea.Cmd) {
+	f := m.finder
+	if msg.finder != f {
+		return m, nil
+	}
+
+	f.scanning = false
+	f.files, f.truncated, f.skipped, f.err = msg.files, msg.truncated, msg.skipped, msg.err
+	f.matches = rankFiles(f.files, m.fileInput.Value())
+	f.cursor = 0
+	return m, nil
+}
+
+// closeFinder leaves the fuzzy finder with path in the input
+func (m *Model) closeFinder(path string) {
+	close(m.finder.stop)
+	m.finder = nil
+	m.fileInput.SetValue(path)
+	m.fileInput.CursorEnd()
+	m.autocomplete.Reset()
+	m.inspectInput()
+}
+
+func (m Model) updateFinder(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.finder
+
+	switch msg.String() {
+	case "esc":
+		m.closeFinder(f.previous)
+		return m, nil
+
+	case "up", "ctrl+p":
+		f.cursor = max(f.cursor-1, 0)
+		return m, nil
+
+	case "down", "ctrl+n":
+		f.cursor = max(min(f.cursor+1, len(f.matches)-1), 0)
+		return m, nil
+
+	case "tab":
+		return m, nil
+
+	case "enter":
+		if f.cursor >= len(f.matches) {
+			return m, nil
+		}
+		m.closeFinder(filepath.Join(f.root, f.matches[f.cursor].path))
+		return m.updateFileSelect(msg)
+	}
+
+	query := m.fileInput.Value()
+	var cmd tea.Cmd
+	m.fileInput, cmd = m.fileInput.Update(msg)
+	if m.fileInput.Value() != query {
+		f.matches = rankFiles(f.files, m.fileInput.Value())
+		f.cursor = 0
+	}
+	return m, cmd
+}
+
+func (m Model) finderView() string {
+	var sb strings.Builder
+	f := m.finder
+
+	if f.err != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", f.err)))
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	if f.scanning {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("Scanning %s...", f.root)))
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	summary := fmt.Sprintf("%d of %d files under %s", len(f.matches), len(f.files), f.root)
+	if f.truncated {
+		summary += fmt.Sprintf(" (stopped at %d files)", finderMaxFiles)
+	}
+	if f.skipped > 0 {
+		summary += fmt.Sprintf(", %d huge directories skipped", f.skipped)
+	}
+	sb.WriteString(dimStyle.Render(summary))
+	sb.WriteString("\n")
+
+	first := max(0, f.cursor-finderShown+1)
+	last := min(len(f.matches), first+finderShown)
+	for i := first; i < last; i++ {
+		match := f.matches[i]
+		if i == f.cursor {
+			sb.WriteString(selectedItemStyle.Render("→ "))
+		} else {
+			sb.WriteString("  ")
+		}
+		sb.WriteString(highlightMatch(match, i == f.cursor))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// highlightMatch renders the path of match with its matched characters
+// highlighted
+func highlightMatch(match fuzzyMatch, selected bool) string {
+	style := dimStyle
+	if selected {
+		style = selectedItemStyle
+	}
+
+	var sb strings.Builder
+	var plain []rune
+	next := 0
+	for i, r := range []rune(match.path) {
+		if next < len(match.positions) && match.positions[next] == i {
+			if len(plain) > 0 {
+				sb.WriteString(style.Render(string(plain)))
+				plain = plain[:0]
+			}
+			sb.WriteString(matchStyle.Render(string(r)))
+			next++
+			continue
+		}
+		plain = append(plain, r)
+	}
+	if len(plain) > 0 {
+		sb.WriteString(style.Render(string(plain)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func writeTree(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("INFO: a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWalkFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"app.log",
+		"deploy/prod/api/logs/api.log",
+		".git/HEAD",
+		"node_modules/pkg/debug.log",
+		"a/b/c/d/e/f/g/h/i/too-deep.log",
+	)
+
+	files, truncated, skipped, err := walkFiles(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"app.log", filepath.FromSlash("deploy/prod/api/logs/api.log")}
+	if !reflect.DeepEqual(files, want) || truncated || skipped != 0 {
+		t.Errorf("walkFiles() = %v, %v, %d, want %v", files, truncated, skipped, want)
+	}
+
+	if _, _, _, err := walkFiles(filepath.Join(root, "missing"), nil); err == nil {
+		t.Error("walkFiles() on a missing directory succeeded")
+	}
+}
+
+func TestRankFiles(t *testing.T) {
+	files := []string{
+		"docs/api/overview.md",
+		"deploy/staging/api/logs/access.log",
+		"deploy/prod/api/logs/error.log",
+		"apache/logs/error.log",
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", files},
+		{"xyz", nil},
+		{"prod error", []string{"deploy/prod/api/logs/error.log"}},
+		{"apierr", []string{"deploy/prod/api/logs/error.log"}},
+		// Segment starts and short gaps win; ties go to the shorter path
+		{"aplog", []string{"deploy/prod/api/logs/error.log", "deploy/staging/api/logs/access.log", "apache/logs/error.log"}},
+		{"error", []string{"apache/logs/error.log", "deploy/prod/api/logs/error.log"}},
+		{"API", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, match := range rankFiles(files, tt.query) {
+			got = append(got, match.path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rankFiles(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyScore_Positions(t *testing.T) {
+	// The window is narrowed to the last "a" before "pi"
+	_, positions, ok := fuzzyScore("a/b/api.log", "api")
+	if !ok || !reflect.DeepEqual(positions, []int{4, 5, 6}) {
+		t.Errorf("positions = %v, %v", positions, ok)
+	}
+}
+
+func TestFinder_Pick(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "README.md", "deploy/prod/api/logs/api.log")
+
+	m := Model{fileInput: textinput.New(), autocomplete: NewAutocomplete(), locationCursor: -1}
+	m.fileInput.Focus()
+	m.fileInput.SetValue(root)
+	m, scan := update(m, tea.KeyMsg{Type: tea.KeyCtrlF})
+	if m.finder == nil || !m.finder.scanning || !strings.Contains(m.finderView(), "Scanning") {
+		t.Fatalf("finder = %+v", m.finder)
+	}
+
+	// Esc restores the path input; the walk of the closed finder is ignored
+	m = press(m, tea.KeyEsc)
+	if m.finder != nil || m.fileInput.Value() != root {
+		t.Fatalf("after esc: finder = %+v, input = %q", m.finder, m.fileInput.Value())
+	}
+	m, rescan := update(m, tea.KeyMsg{Type: tea.KeyCtrlF})
+	m, _ = update(m, scan())
+	if !m.finder.scanning {
+		t.Fatal("finder took the files of a closed finder")
+	}
+
+	m, _ = update(m, rescan())
+	if m.finder.root != root || m.finder.scanning || len(m.finder.matches) != 2 || m.fileInput.Value() != "" {
+		t.Fatalf("finder = %+v, input = %q", m.finder, m.fileInput.Value())
+	}
+	m, _ = update(m, typed("apilog"))
+	if len(m.finder.matches) != 1 || !strings.Contains(m.finderView(), "1 of 2 files") {
+		t.Fatalf("matches = %+v", m.finder.matches)
+	}
+	m = press(m, tea.KeyEnter)
+	want := filepath.Join(root, "deploy", "prod", "api", "logs", "api.log")
+	if m.finder != nil || m.screen != screenFilterManage || m.filePath != want {
+		t.Errorf("screen = %v, filePath = %q, want %q", m.screen, m.filePath, want)
+	}
+}
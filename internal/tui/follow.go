@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+const followMaxShown = 12
+
+// followSession is a running Follow whose progress is delivered as
+// followMsg values on updates, ending with a followDoneMsg
+type followSession struct {
+	outputPath string
+	cancel     context.CancelFunc
+	updates    chan tea.Msg
+}
+
+type followMsg struct {
+	session *followSession
+	stats   cleaner.Stats
+	lines   []string
+}
+
+type followDoneMsg struct {
+	session *followSession
+	stats   *cleaner.Stats
+	err     error
+}
+
+// startFollow starts following inputPath, appending kept lines to
+// outputPath. The output is converted back to the input encoding when
+// preserve is set, not by opts.
+func startFollow(filters []*filter.Filter, opts cleaner.Options, inputPath, outputPath string, detection *textenc.Detection, preserve bool) (*followSession, error) {
+	// Appending to the followed file would read each kept line again
+	if overwritesInput([]string{outputPath}, []string{inputPath}) {
+		return nil, fmt.Errorf("the output %s is the followed file", outputPath)
+	}
+	file, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	var output io.WriteCloser = file
+	if preserve && detection != nil {
+		// Appended output never starts with a byte order mark
+		d := *detection
+		d.BOM = false
+		output = textenc.NewEncoder(file, d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &followSession{
+		outputPath: outputPath,
+		cancel:     cancel,
+		updates:    make(chan tea.Msg, 1),
+	}
+
+	go func() {
+		defer file.Close()
+
+		tail := &lineTail{w: output, max: followMaxShown}
+		stats, err := cleaner.NewWithOptions(filters, opts).Follow(ctx, inputPath, tail, cleaner.FollowOptions{
+			OnUpdate: func(s cleaner.Stats) {
+				session.update(followMsg{session: session, stats: s, lines: tail.snapshot()})
+			},
+		})
+		if closeErr := output.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write output: %w", closeErr)
+		}
+		session.updates <- followDoneMsg{session: session, stats: stats, err: err}
+	}()
+
+	return session, nil
+}
+
+// update replaces an update the UI has not taken yet, so the latest one is
+// shown even if no further update follows. The follow goroutine is the only
+// sender.
+func (session *followSession) update(msg followMsg) {
+	select {
+	case <-session.updates:
+	default:
+	}
+	session.updates <- msg
+}
+
+// waitForFollow delivers the next message of a follow session
+func waitForFollow(session *followSession) tea.Cmd {
+	return func() tea.Msg {
+		return <-session.updates
+	}
+}
+
+// lineTail passes writes through to w and remembers the last max complete
+// lines written
+type lineTail struct {
+	w       io.Writer
+	max     int
+	partial []byte
+	lines   []string
+}
+
+func (t *lineTail) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+
+	data := p[:n]
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.partial = append(t.partial, data...)
+			break
+		}
+		line := append(t.partial, data[:i]...)
+		t.partial = t.partial[:0]
+		t.lines = append(t.lines, strings.TrimSuffix(string(line), "\r"))
+		data = data[i+1:]
+	}
+	if len(t.lines) > t.max {
+		t.lines = append(t.lines[:0], t.lines[len(t.lines)-t.max:]...)
+	}
+
+	return n, err
+}
+
+func (t *lineTail) snapshot() []string {
+	return append([]string(nil), t.lines...)
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+)
+
+func TestLineTail(t *testing.T) {
+	var out bytes.Buffer
+	tail := &lineTail{w: &out, max: 2}
+
+	for _, chunk := range []string{"one\ntw", "o\r\nthree\n", "fo"} {
+		if _, err := tail.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := out.String(); got != "one\ntwo\r\nthree\nfo" {
+		t.Errorf("output = %q, want writes passed through", got)
+	}
+	if got, want := tail.snapshot(), []string{"two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() = %q, want %q", got, want)
+	}
+}
+
+func TestFollowSession_LatestUpdateWins(t *testing.T) {
+	session := &followSession{updates: make(chan tea.Msg, 1)}
+	for lines := 1; lines <= 3; lines++ {
+		session.update(followMsg{session: session, stats: cleaner.Stats{TotalLines: lines}})
+	}
+
+	msg := waitForFollow(session)().(followMsg)
+	if msg.stats.TotalLines != 3 {
+		t.Errorf("delivered update with %d lines, want the latest with 3", msg.stats.TotalLines)
+	}
+}
+
+func TestStartFollow_RefusesInputAsOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("INFO: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if session, err := startFollow(nil, cleaner.Options{}, path, path, nil, false); err == nil {
+		session.cancel()
+		t.Fatal("startFollow() appended to the followed file")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "INFO: a\n" {
+		t.Errorf("input = %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+// resultSuppressed is the number of shapes removed by frequency filters
+// listed on the results screen
+const resultSuppressed = 10
+
+// defaultThreshold is the amount a new filter starts with
+const defaultThreshold = "100"
+
+// filterTypes is the order the type selector cycles through
+var filterTypes = []filter.FilterType{filter.TypeRemove, filter.TypeKeep, filter.TypeFrequency, filter.TypeSample}
+
+// cycleFilterType returns the type step places after t in filterTypes
+func cycleFilterType(t filter.FilterType, step int) filter.FilterType {
+	for i, ft := range filterTypes {
+		if ft == t {
+			return filterTypes[(i+step+len(filterTypes))%len(filterTypes)]
+		}
+	}
+	return filter.TypeRemove
+}
+
+// amountLabel returns the label and hint of the amount input, which only
+// frequency and sample filters have
+func amountLabel(t filter.FilterType) (string, string) {
+	switch t {
+	case filter.TypeFrequency:
+		return "Threshold:", "Shapes with more lines are removed"
+	case filter.TypeSample:
+		return "Keep:", "10: one in 10 lines, 5/1m: up to 5 lines of each shape per minute"
+	}
+	return "", ""
+}
+
+// filterAddFields is the number of focusable fields on the add screen
+func (m Model) filterAddFields() int {
+	if label, _ := amountLabel(m.newFilterType); label != "" {
+		return 5
+	}
+	return 4
+}
+
+// buildNewFilter creates the filter described by the add screen inputs.
+// Frequency and sample filters without a pattern apply to all lines.
+func (m Model) buildNewFilter() (*filter.Filter, error) {
+	name := strings.TrimSpace(m.newFilterName.Value())
+	pattern := strings.TrimSpace(m.newFilterPattern.Value())
+	amount := strings.TrimSpace(m.newFilterAmount.Value())
+
+	switch m.newFilterType {
+	case filter.TypeFrequency:
+		threshold, err := strconv.Atoi(amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold: %w", err)
+		}
+		return filter.NewFrequency(name, pattern, threshold, m.newFilterOptions)
+
+	case filter.TypeSample:
+		limit, interval, ok := strings.Cut(amount, "/")
+		if !ok {
+			rate, err := strconv.Atoi(amount)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sample rate: %w", err)
+			}
+			return filter.NewSample(name, pattern, rate, m.newFilterOptions)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit: %w", err)
+		}
+		d, err := parseInterval(interval)
+		if err != nil {
+			return nil, err
+		}
+		return filter.NewRateLimit(name, pattern, n, d, m.newFilterOptions)
+	}
+
+	return filter.NewWithOptions(name, pattern, m.newFilterType, m.newFilterOptions)
+}
+
+// parseInterval accepts a duration such as 30s or 1m, or a bare unit
+func parseInterval(s string) (time.Duration, error) {
+	switch s {
+	case "s", "m", "h":
+		s = "1" + s
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate limit interval: %w", err)
+	}
+	return d, nil
+}
+
+// formatInterval drops the zero units time.Duration.String adds, as in 1m0s
+func formatInterval(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// filterTypeLabel describes the type of f in the filter list
+func filterTypeLabel(f *filter.Filter) string {
+	switch f.Type {
+	case filter.TypeRemove:
+		return "🗑️  Remove"
+	case filter.TypeFrequency:
+		return fmt.Sprintf("📊 Frequency >%d", f.Threshold)
+	case filter.TypeSample:
+		if f.RateLimited() {
+			return fmt.Sprintf("🎲 Sample %d/%s", f.Limit, formatInterval(time.Duration(f.Interval)))
+		}
+		return fmt.Sprintf("🎲 Sample 1 in %d", f.Rate)
+	}
+	return "✅ Keep"
+}
+
+func (m Model) suppressedView() string {
+	var sb strings.Builder
+
+	sb.WriteString(subtitleStyle.Render("Suppressed by frequency filters:"))
+	sb.WriteString("\n\n")
+
+	maxLen := m.width - 16
+	if maxLen < 40 {
+		maxLen = 80
+	}
+	for _, shape := range m.stats.TopSuppressed(resultSuppressed) {
+		sb.WriteString(itemStyle.Render(fmt.Sprintf("  %8d  %s", shape.Count, truncate(shape.Shape, maxLen))))
+		sb.WriteString("\n")
+	}
+	if more := len(m.stats.Suppressed) - resultSuppressed; more > 0 {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more shapes", more)))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestBuildNewFilter_Frequency(t *testing.T) {
+	m := Model{
+		newFilterName:    textinput.New(),
+		newFilterPattern: textinput.New(),
+		newFilterAmount:  textinput.New(),
+	}
+	m.newFilterName.SetValue("noise")
+
+	m.newFilterType = cycleFilterType(filter.TypeSample, -1)
+	if m.newFilterType != filter.TypeFrequency || m.filterAddFields() != 5 {
+		t.Fatalf("type = %q, want frequency with a threshold field", m.newFilterType)
+	}
+
+	m.newFilterAmount.SetValue("many")
+	if _, err := m.buildNewFilter(); err == nil {
+		t.Error("buildNewFilter() accepted a non-numeric threshold")
+	}
+
+	m.newFilterAmount.SetValue(" 50 ")
+	f, err := m.buildNewFilter()
+	if err != nil {
+		t.Fatalf("buildNewFilter() error = %v", err)
+	}
+	if f.Pattern != "" || f.Threshold != 50 || filterTypeLabel(f) != "📊 Frequency >50" {
+		t.Errorf("filter = %+v", f)
+	}
+
+	// Other types still need a pattern
+	m.newFilterType = cycleFilterType(m.newFilterType, -1)
+	if _, err := m.buildNewFilter(); err == nil {
+		t.Errorf("buildNewFilter() accepted an empty %s pattern", m.newFilterType)
+	}
+}
+
+func TestBuildNewFilter_Sample(t *testing.T) {
+	m := Model{
+		newFilterName:    textinput.New(),
+		newFilterPattern: textinput.New(),
+		newFilterAmount:  textinput.New(),
+		newFilterType:    filter.TypeSample,
+	}
+	m.newFilterName.SetValue("debug")
+
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"10", "🎲 Sample 1 in 10"},
+		{"5/1m", "🎲 Sample 5/1m"},
+		{"5/m", "🎲 Sample 5/1m"},
+		{"2/90s", "🎲 Sample 2/1m30s"},
+		{"1/2h", "🎲 Sample 1/2h"},
+	}
+	for _, tt := range tests {
+		m.newFilterAmount.SetValue(tt.amount)
+		f, err := m.buildNewFilter()
+		if err != nil {
+			t.Errorf("buildNewFilter(%q) error = %v", tt.amount, err)
+			continue
+		}
+		if got := filterTypeLabel(f); got != tt.want {
+			t.Errorf("buildNewFilter(%q) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+
+	for _, amount := range []string{"1", "5/soon", "x/1m"} {
+		m.newFilterAmount.SetValue(amount)
+		if _, err := m.buildNewFilter(); err == nil {
+			t.Errorf("buildNewFilter(%q) should fail", amount)
+		}
+	}
+}
+
+func TestFilterAdd_ShowsInvalidAmount(t *testing.T) {
+	m := Model{
+		screen:           screenFilterAdd,
+		newFilterName:    textinput.New(),
+		newFilterPattern: textinput.New(),
+		newFilterAmount:  textinput.New(),
+		newFilterType:    filter.TypeFrequency,
+	}
+	m.newFilterName.SetValue("noise")
+	m.newFilterAmount.SetValue("many")
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if m.screen != screenFilterAdd || !strings.Contains(m.filterAddView(), "invalid threshold") {
+		t.Fatalf("screen = %v, view =\n%s", m.screen, m.filterAddView())
+	}
+
+	// The error is cleared by the next edit
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m = next.(Model); m.filterErr != nil {
+		t.Errorf("filterErr = %v after editing", m.filterErr)
+	}
+}
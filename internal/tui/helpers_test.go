@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/storage"
+)
+
+// setupStorage opens the storage in a temporary home directory
+func setupStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	store, err := storage.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// update feeds msg to m and returns the updated model with its command
+func update(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	next, cmd := m.Update(msg)
+	return next.(Model), cmd
+}
+
+// press feeds the key k to m
+func press(m Model, k tea.KeyType) Model {
+	m, _ = update(m, tea.KeyMsg{Type: k})
+	return m
+}
+
+// typed is the key message for typing s
+func typed(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/histogram"
+	"github.com/sstreichan/logcleaner/internal/report"
+	"github.com/sstreichan/logcleaner/internal/severity"
+)
+
+// levelBarWidth is the width of the bars in the level breakdown
+const levelBarWidth = 24
+
+// levelOrder lists the levels from most to least severe
+var levelOrder = []severity.Level{
+	severity.Fatal, severity.Error, severity.Warn, severity.Info,
+	severity.Debug, severity.Trace, severity.Unknown,
+}
+
+func (m Model) histogramView(h *histogram.Histogram) string {
+	var sb strings.Builder
+
+	columns := m.width - 12
+	if columns < 20 {
+		columns = 60
+	}
+	series := h.Series(columns)
+	if len(series.Counts) == 0 {
+		return ""
+	}
+
+	total := make([]int, len(series.Counts))
+	kept := make([]int, len(series.Counts))
+	peak := 0
+	for i, c := range series.Counts {
+		total[i], kept[i] = c.Total, c.Kept
+		if c.Total > series.Counts[peak].Total {
+			peak = i
+		}
+	}
+	highest := series.Counts[peak].Total
+
+	sb.WriteString(subtitleStyle.Render(fmt.Sprintf("Lines over time (%s, %s per column):",
+		timeRange(series.Start, series.End()), formatInterval(series.Step))))
+	sb.WriteString("\n")
+	sb.WriteString(dimStyle.Render("Before "))
+	sb.WriteString(report.Sparkline(total, highest))
+	sb.WriteString("\n")
+	sb.WriteString(dimStyle.Render("After  "))
+	sb.WriteString(infoStyle.Render(report.Sparkline(kept, highest)))
+	sb.WriteString("\n")
+
+	at := series.Start.Add(time.Duration(peak) * series.Step)
+	sb.WriteString(dimStyle.Render(fmt.Sprintf("Peak: %d lines at %s, %d kept",
+		highest, at.Format("2006-01-02 15:04:05"), series.Counts[peak].Kept)))
+	if untimed := h.Untimed(); untimed.Total > 0 {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf(" | %d lines before the first timestamp", untimed.Total)))
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+func (m Model) levelsView(h *histogram.Histogram) string {
+	var sb strings.Builder
+
+	highest := 0
+	for _, c := range h.Levels {
+		if c.Total > highest {
+			highest = c.Total
+		}
+	}
+	if highest == 0 {
+		return ""
+	}
+
+	sb.WriteString(subtitleStyle.Render("Levels (kept █ / removed ░):"))
+	sb.WriteString("\n")
+	for _, level := range levelOrder {
+		c := h.Levels[level]
+		if c.Total == 0 {
+			continue
+		}
+		width := (c.Total*levelBarWidth + highest - 1) / highest
+		keptWidth := c.Kept * width / c.Total
+		if c.Kept > 0 && keptWidth == 0 {
+			keptWidth = 1
+		}
+		bar := strings.Repeat("█", keptWidth) + strings.Repeat("░", width-keptWidth)
+		sb.WriteString(fmt.Sprintf("%-8s %-*s %8d → %d\n", level, levelBarWidth, bar, c.Total, c.Kept))
+	}
+
+	return sb.String()
+}
+
+// timeRange formats the span from start to end, leaving out the end date
+// if it is the same day
+func timeRange(start, end time.Time) string {
+	const layout = "2006-01-02 15:04:05"
+	last := end.Add(-time.Second)
+	if last.Year() == start.Year() && last.YearDay() == start.YearDay() {
+		return start.Format(layout) + " – " + end.Format("15:04:05")
+	}
+	return start.Format(layout) + " – " + end.Format(layout)
+}
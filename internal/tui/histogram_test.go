@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/histogram"
+)
+
+func TestTimeRange(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	if got := timeRange(start, start.Add(time.Hour)); got != "2024-01-31 12:00:00 – 13:00:00" {
+		t.Errorf("timeRange() = %q", got)
+	}
+	if got := timeRange(start, start.Add(12*time.Hour)); got != "2024-01-31 12:00:00 – 00:00:00" {
+		t.Errorf("timeRange() up to midnight = %q", got)
+	}
+	if got := timeRange(start, start.Add(36*time.Hour)); got != "2024-01-31 12:00:00 – 2024-02-02 00:00:00" {
+		t.Errorf("timeRange() over days = %q", got)
+	}
+}
+
+func TestHistogramViews(t *testing.T) {
+	h := histogram.New()
+	for _, line := range []string{
+		"2024-01-31T12:00:00Z INFO ready",
+		"2024-01-31T12:00:30Z ERROR failed",
+		"2024-01-31T12:01:00Z INFO ok",
+	} {
+		h.Add([]byte(line), !strings.Contains(line, "INFO"))
+	}
+
+	m := Model{width: 80}
+	timeline := m.histogramView(h)
+	if !strings.Contains(timeline, "Peak: 1 lines at 2024-01-31 12:00:00, 0 kept") {
+		t.Errorf("histogramView() =\n%s", timeline)
+	}
+
+	levels := m.levelsView(h)
+	if !strings.Contains(levels, "ERROR    "+strings.Repeat("█", 12)) || !strings.Contains(levels, strings.Repeat("░", levelBarWidth)+"        2 → 0") {
+		t.Errorf("levelsView() =\n%s", levels)
+	}
+	if strings.Index(levels, "ERROR") > strings.Index(levels, "INFO") {
+		t.Error("levelsView() should list the most severe levels first")
+	}
+
+	if m.histogramView(histogram.New()) != "" || m.levelsView(histogram.New()) != "" {
+		t.Error("views of an empty histogram should be empty")
+	}
+}
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/output"
+	"github.com/sstreichan/logcleaner/internal/storage"
+)
+
+// historyShown is how many runs the history screen lists at once
+const historyShown = 12
+
+// activeFilters returns the filters of the current run: the snapshot of a
+// run repeated from the history, or the saved filters
+func (m Model) activeFilters() []*filter.Filter {
+	if m.replay != nil {
+		return m.replay
+	}
+	return m.filters
+}
+
+// runMode returns the storage mode of the current run
+func (m Model) runMode() string {
+	switch {
+	case m.mergeFiles:
+		return storage.RunMerge
+	case m.batchFiles != nil:
+		return storage.RunBatch
+	case m.mergeRotation:
+		return storage.RunRotated
+	case m.inPlace:
+		return storage.RunInPlace
+	}
+	return storage.RunClean
+}
+
+// addHistory records the finished run in the history
+func (m *Model) addHistory(stats *cleaner.Stats, runErr error) {
+	if m.storage == nil {
+		return
+	}
+
+	run := storage.Run{
+		Started:          m.started,
+		Duration:         time.Since(m.started).Round(time.Millisecond),
+		Mode:             m.runMode(),
+		Outputs:          m.outputPaths(),
+		Profile:          m.profile,
+		Filters:          m.activeFilters(),
+		DedupWindow:      m.dedupWindow,
+		DedupExact:       m.dedupExact,
+		PreserveEncoding: m.preserveEncoding,
+		Labels:           m.labelLines,
+		Backup:           m.backup,
+		Inputs:           m.runInputs(),
+	}
+	run.SetStats(stats)
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+
+	m.historyErr = m.storage.AddRun(run)
+}
+
+// batchError summarizes the failed files of a batch, if any
+func batchError(result *cleaner.BatchResult) error {
+	if failed := result.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d files failed", len(failed), len(result.Files))
+	}
+	return nil
+}
+
+func (m Model) openHistory() (tea.Model, tea.Cmd) {
+	m.history, m.historyErr = m.storage.LoadHistory()
+	m.historyCursor = 0
+	m.screen = screenHistory
+	return m, nil
+}
+
+func (m Model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+
+	case "esc":
+		m.screen = screenFileSelect
+		m.historyErr = nil
+
+	case "up", "k":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+
+	case "down", "j":
+		if m.historyCursor < len(m.history)-1 {
+			m.historyCursor++
+		}
+
+	case "enter":
+		if m.historyCursor < len(m.history) {
+			return m.rerun(m.history[m.historyCursor])
+		}
+	}
+
+	return m, nil
+}
+
+// rerun repeats a recorded run on the same inputs and outputs with its
+// filter snapshot; the saved filters are left as they are. Batch outputs
+// are named by the current template.
+func (m Model) rerun(run storage.Run) (tea.Model, tea.Cmd) {
+	if len(run.Inputs) == 0 || len(run.Outputs) == 0 {
+		m.historyErr = fmt.Errorf("the run records no files")
+		return m, nil
+	}
+	for _, input := range run.Inputs {
+		if _, err := os.Stat(input); err != nil {
+			m.historyErr = fmt.Errorf("input no longer exists: %s", input)
+			return m, nil
+		}
+	}
+
+	m.filePath = run.Inputs[0]
+	m.batchFiles = nil
+	m.rotationFiles, m.mergeRotation = nil, false
+	m.mergeFiles, m.labelLines = false, false
+	m.inPlace, m.backup = false, false
+	m.outputOverride = ""
+	m.detection = nil
+
+	switch run.Mode {
+	case storage.RunBatch:
+		m.batchFiles = run.Inputs
+	case storage.RunMerge:
+		m.batchFiles = run.Inputs
+		m.mergeFiles, m.labelLines = true, run.Labels
+		m.outputOverride = run.Outputs[0]
+	case storage.RunRotated:
+		// Rotation sets are recorded oldest first, ending with the file itself
+		m.filePath = run.Inputs[len(run.Inputs)-1]
+		m.rotationFiles, m.mergeRotation = run.Inputs, true
+		m.outputOverride = run.Outputs[0]
+	case storage.RunInPlace:
+		m.inPlace, m.backup = true, run.Backup
+	default:
+		m.outputOverride = run.Outputs[0]
+	}
+
+	m.dedupWindow, m.dedupExact = run.DedupWindow, run.DedupExact
+	m.preserveEncoding = run.PreserveEncoding
+	m.replay = run.Filters
+	if m.replay == nil {
+		m.replay = []*filter.Filter{}
+	}
+	m.historyErr = nil
+	return m.process()
+}
+
+func (m Model) historyView() string {
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render("🕘 Run History"))
+	sb.WriteString("\n\n")
+
+	if m.historyErr != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.historyErr)))
+		sb.WriteString("\n\n")
+	}
+	if len(m.history) == 0 {
+		sb.WriteString(dimStyle.Render("No runs yet."))
+		sb.WriteString("\n\n")
+		sb.WriteString(helpStyle.Render("Esc: back | Ctrl+C: quit"))
+		return sb.String()
+	}
+
+	first := max(0, m.historyCursor-historyShown+1)
+	last := min(len(m.history), first+historyShown)
+	for i := first; i < last; i++ {
+		line := historyLine(m.history[i])
+		if i == m.historyCursor {
+			sb.WriteString(selectedItemStyle.Render("→ " + line))
+		} else {
+			sb.WriteString(itemStyle.Render("  " + line))
+		}
+		sb.WriteString("\n")
+	}
+	if len(m.history) > historyShown {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("  %d/%d", m.historyCursor+1, len(m.history))))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(historyDetails(m.history[m.historyCursor]))
+	sb.WriteString("\n")
+	sb.WriteString(helpStyle.Render("↑/↓: select | Enter: re-run with these filters | Esc: back | Ctrl+C: quit"))
+
+	return sb.String()
+}
+
+// historyLine summarizes a run in one line
+func historyLine(run storage.Run) string {
+	name := "?"
+	if len(run.Inputs) > 0 {
+		name = filepath.Base(run.Inputs[len(run.Inputs)-1])
+	}
+	if len(run.Inputs) > 1 {
+		name = fmt.Sprintf("%s +%d", name, len(run.Inputs)-1)
+	}
+
+	result := "failed"
+	if run.Error == "" && run.Stats != nil {
+		result = fmt.Sprintf("%d of %d lines removed", run.Stats.TotalLines-run.Stats.RemainingLines(), run.Stats.TotalLines)
+	}
+	return fmt.Sprintf("%s  %-8s  %-28s  %s", run.Started.Format("2006-01-02 15:04"), run.Mode, name, result)
+}
+
+// historyDetails lists the files, settings and filters of a run
+func historyDetails(run storage.Run) string {
+	var sb strings.Builder
+
+	profile := run.Profile
+	if profile == "" {
+		profile = output.DefaultProfile
+	}
+	sb.WriteString(labelStyle.Render(fmt.Sprintf("%s run, profile %s, took %s", run.Mode, profile, run.Duration)))
+	sb.WriteString("\n")
+	if run.Error != "" {
+		sb.WriteString(errorStyle.Render("Error: " + run.Error))
+		sb.WriteString("\n")
+	}
+	for _, input := range run.Inputs {
+		sb.WriteString(dimStyle.Render("  in:  " + input))
+		sb.WriteString("\n")
+	}
+	for _, out := range run.Outputs {
+		sb.WriteString(dimStyle.Render("  out: " + out))
+		sb.WriteString("\n")
+	}
+
+	if len(run.Filters) == 0 {
+		sb.WriteString(dimStyle.Render("  No filters"))
+		sb.WriteString("\n")
+	}
+	for i, f := range run.Filters {
+		hits := 0
+		if run.Stats != nil && i < len(run.Stats.FilterHits) {
+			hits = run.Stats.FilterHits[i]
+		}
+		pattern := f.Pattern
+		if pattern == "" {
+			pattern = "(all lines)"
+		}
+		sb.WriteString(itemStyle.Render(fmt.Sprintf("  %s %s: %s (%d removed)", filterTypeLabel(f), f.Name, pattern, hits)))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
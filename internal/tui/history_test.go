@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/output"
+	"github.com/sstreichan/logcleaner/internal/storage"
+)
+
+func TestHistory_RecordAndRerun(t *testing.T) {
+	store := setupStorage(t)
+	namer, err := output.NewNamer("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(inputPath, []byte("INFO: a\nDEBUG: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	debug, _ := filter.New("debug", "^DEBUG", filter.TypeRemove)
+
+	run := func(m Model, cmd tea.Cmd) Model {
+		m, _ = update(m, cmd())
+		return m
+	}
+
+	m := Model{storage: store, namer: namer, filePath: inputPath, filters: []*filter.Filter{debug}, autocomplete: NewAutocomplete()}
+	next, cmd := m.startProcessing()
+	m = run(next.(Model), cmd)
+
+	runs, err := store.LoadHistory()
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("history = %v, %v", runs, err)
+	}
+	if r := runs[0]; r.Mode != storage.RunClean || r.Inputs[0] != inputPath || len(r.Filters) != 1 || r.Stats.FilteredLines != 1 {
+		t.Errorf("recorded run = %+v", r)
+	}
+
+	// The saved filters change after the run; a re-run uses the snapshot
+	m = press(m, tea.KeyEnter)
+	m.filters = nil
+	m = press(m, tea.KeyCtrlR)
+	if m.screen != screenHistory || !strings.Contains(m.historyView(), "debug") {
+		t.Fatalf("screen = %v, view:\n%s", m.screen, m.historyView())
+	}
+	m = press(m, tea.KeyEnter)
+	if m.screen != screenConfirmOverwrite {
+		t.Fatalf("screen = %v, want the overwrite confirmation", m.screen)
+	}
+	m, cmd = update(m, typed("y"))
+	m = run(m, cmd)
+	if m.err != nil || m.stats == nil || m.stats.FilteredLines != 1 {
+		t.Fatalf("re-run stats = %+v, err = %v", m.stats, m.err)
+	}
+	if got, _ := os.ReadFile(inputPath + ".cleaned"); string(got) != "INFO: a\n" {
+		t.Errorf("re-run output = %q", got)
+	}
+	if runs, _ := store.LoadHistory(); len(runs) != 2 {
+		t.Errorf("got %d runs, want the re-run recorded", len(runs))
+	}
+
+	m = press(m, tea.KeyEnter)
+	if m.replay != nil || len(m.activeFilters()) != 0 {
+		t.Error("the snapshot should only apply to the re-run")
+	}
+}
@@ -0,0 +1,251 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/rotation"
+)
+
+const (
+	// listingCacheSize is how many directory listings are kept
+	listingCacheSize = 32
+	// listingMaxProbe is the number of files up to which a listing reads
+	// each file to detect its compression; above it the extension is used
+	listingMaxProbe = 500
+)
+
+// dirEntry is a completion candidate with its metadata
+type dirEntry struct {
+	name        string
+	dir         bool
+	size        int64
+	modTime     time.Time
+	compression string // see rotation.Compression
+}
+
+// dirListing is a cached directory listing. It is current as long as the
+// directory has the mtime it was read at; changes to the files themselves
+// are picked up with the next change to the directory.
+type dirListing struct {
+	modTime time.Time
+	read    time.Time
+	entries []dirEntry
+	index   map[string]int // entries by name
+}
+
+// listingMsg delivers a directory read in the background
+type listingMsg struct {
+	dir     string
+	listing *dirListing
+	err     error
+}
+
+// readListing reads the entries of dir with their size, modification time
+// and compression
+func readListing(dir string, modTime time.Time) (*dirListing, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	probe := len(entries) <= listingMaxProbe
+	listing := &dirListing{modTime: modTime, read: time.Now(), index: make(map[string]int, len(entries))}
+	for _, entry := range entries {
+		e := dirEntry{name: entry.Name(), dir: entry.IsDir()}
+		if info, err := entry.Info(); err == nil {
+			e.size, e.modTime = info.Size(), info.ModTime()
+		}
+		if !e.dir {
+			e.compression = rotation.CompressionByName(e.name)
+			if probe && entry.Type().IsRegular() {
+				e.compression, _ = rotation.Compression(filepath.Join(dir, e.name))
+			}
+		}
+		listing.index[e.name] = len(listing.entries)
+		listing.entries = append(listing.entries, e)
+	}
+	return listing, nil
+}
+
+// load reads dir into the cache unless the cached listing is current
+func (a *Autocomplete) load(dir string) {
+	dir = filepath.Clean(dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		a.store(dir, nil, err)
+		return
+	}
+	if cached := a.cache[dir]; cached != nil && cached.modTime.Equal(info.ModTime()) {
+		return
+	}
+	listing, err := readListing(dir, info.ModTime())
+	a.store(dir, listing, err)
+}
+
+// List returns a command that reads dir in the background, or nil once it
+// finds the cached listing still current
+func (a *Autocomplete) List(dir string) tea.Cmd {
+	dir = filepath.Clean(dir)
+	cached := a.cache[dir]
+	return func() tea.Msg {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return listingMsg{dir: dir, err: err}
+		}
+		if cached != nil && cached.modTime.Equal(info.ModTime()) {
+			return nil
+		}
+		listing, err := readListing(dir, info.ModTime())
+		return listingMsg{dir: dir, listing: listing, err: err}
+	}
+}
+
+// Cached reports whether a listing of dir is cached
+func (a *Autocomplete) Cached(dir string) bool {
+	return a.cache[filepath.Clean(dir)] != nil
+}
+
+// store caches a listing, dropping the oldest when the cache is full, or
+// forgets dir if it could not be read
+func (a *Autocomplete) store(dir string, listing *dirListing, err error) {
+	if err != nil || listing == nil {
+		delete(a.cache, dir)
+		return
+	}
+	if _, ok := a.cache[dir]; !ok && len(a.cache) >= listingCacheSize {
+		oldest := ""
+		for key, l := range a.cache {
+			if oldest == "" || l.read.Before(a.cache[oldest].read) {
+				oldest = key
+			}
+		}
+		delete(a.cache, oldest)
+	}
+	a.cache[dir] = listing
+}
+
+// Entry returns the metadata of a match
+func (a *Autocomplete) Entry(match string) (dirEntry, bool) {
+	path := filepath.Clean(match)
+	listing := a.cache[filepath.Dir(path)]
+	if listing == nil {
+		return dirEntry{}, false
+	}
+	i, ok := listing.index[filepath.Base(path)]
+	if !ok {
+		return dirEntry{}, false
+	}
+	return listing.entries[i], true
+}
+
+// Pending returns the input whose completion waits for its directory
+func (a *Autocomplete) Pending() string {
+	return a.pending
+}
+
+// SortedByTime reports whether matches are sorted newest first
+func (a *Autocomplete) SortedByTime() bool {
+	return a.byTime
+}
+
+// ToggleSort switches between directories first and newest first, keeping
+// the current match selected
+func (a *Autocomplete) ToggleSort() {
+	a.byTime = !a.byTime
+	a.refresh()
+}
+
+// sortEntries orders entries directories first and then by name, or newest
+// first
+func (a *Autocomplete) sortEntries(entries []dirEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if a.byTime {
+			return entries[i].modTime.After(entries[j].modTime)
+		}
+		if entries[i].dir != entries[j].dir {
+			return entries[i].dir
+		}
+		return entries[i].name < entries[j].name
+	})
+}
+
+// refresh recomputes the shown matches from the cache after their
+// directory was read again or the sort order changed
+func (a *Autocomplete) refresh() {
+	if a.lastInput == "" || len(a.lastMatches) == 0 {
+		return
+	}
+	selected := a.lastMatches[a.currentIndex]
+	a.lastMatches = a.findMatches(a.parseInput(a.lastInput))
+	a.currentIndex = max(slices.Index(a.lastMatches, selected), 0)
+}
+
+// completeInput completes the path input. An uncached directory is read in
+// the background and the completion applied once it arrives; a cached one
+// is completed right away and revalidated in the background.
+func (m Model) completeInput() (tea.Model, tea.Cmd) {
+	input := m.fileInput.Value()
+	dir, _ := m.autocomplete.parseInput(input)
+	cmd := m.autocomplete.List(dir)
+	if input != "" && !m.autocomplete.Cached(dir) {
+		m.autocomplete.pending = input
+		return m, cmd
+	}
+
+	m.applyCompletion(m.autocomplete.complete(input))
+	return m, cmd
+}
+
+// applyCompletion puts completion into the path input
+func (m *Model) applyCompletion(completion string) {
+	if completion != "" && completion != m.fileInput.Value() {
+		m.fileInput.SetValue(completion)
+		m.fileInput.SetCursor(len(completion))
+		m.inspectInput()
+	}
+}
+
+// updateListing caches a directory read in the background and completes
+// the input that waited for it
+func (m Model) updateListing(msg listingMsg) (tea.Model, tea.Cmd) {
+	a := m.autocomplete
+	a.store(msg.dir, msg.listing, msg.err)
+
+	pending := a.pending
+	if pending == "" {
+		if lastDir, _ := a.parseInput(a.lastInput); filepath.Clean(lastDir) == msg.dir {
+			a.refresh()
+		}
+		return m, nil
+	}
+	if dir, _ := a.parseInput(pending); filepath.Clean(dir) != msg.dir {
+		return m, nil
+	}
+
+	a.pending = ""
+	if m.screen == screenFileSelect && m.finder == nil && m.fileInput.Value() == pending {
+		m.applyCompletion(a.complete(pending))
+	}
+	return m, nil
+}
+
+// entryDetails formats the size, modification time and compression of a
+// match
+func entryDetails(entry dirEntry) string {
+	details := []string{entry.modTime.Format("2006-01-02 15:04")}
+	if !entry.dir {
+		details = append([]string{formatSize(entry.size)}, details...)
+	}
+	if entry.compression != "" {
+		details = append(details, entry.compression)
+	}
+	return strings.Join(details, "  ")
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAutocomplete_CacheByMtime(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "app.log")
+
+	a := NewAutocomplete()
+	input := filepath.Join(dir, "a")
+	if got := a.Complete(input); got != filepath.Join(dir, "app.log") {
+		t.Fatalf("Complete() = %s", got)
+	}
+
+	// A new file is not seen while the directory keeps its mtime
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, dir, "api.log")
+	if err := os.Chtimes(dir, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	a.Reset()
+	if got := a.Complete(input); got != filepath.Join(dir, "app.log") {
+		t.Errorf("Complete() with cached listing = %s", got)
+	}
+
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(dir, later, later); err != nil {
+		t.Fatal(err)
+	}
+	a.Reset()
+	if got := a.Complete(input); got != filepath.Join(dir, "ap") {
+		t.Errorf("Complete() after the directory changed = %s", got)
+	}
+}
+
+func TestAutocomplete_SortAndMetadata(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "b.log", "c.log", "a/x.log")
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "c.log"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "d.log.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte("INFO: a\n"))
+	gz.Close()
+	f.Close()
+	if err := os.Chtimes(filepath.Join(dir, "d.log.1"), old.Add(-time.Hour), old.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	newest := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "b.log"), newest, newest); err != nil {
+		t.Fatal(err)
+	}
+
+	sep := string(filepath.Separator)
+	a := NewAutocomplete()
+	a.Complete(dir + sep)
+	names := func() []string {
+		var names []string
+		for _, match := range a.GetLastMatches() {
+			names = append(names, strings.TrimPrefix(match, dir+sep))
+		}
+		return names
+	}
+	if got, want := names(), []string{"a" + sep, "b.log", "c.log", "d.log.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("directories first = %v, want %v", got, want)
+	}
+
+	a.ToggleSort()
+	if got := names(); got[0] != "b.log" || got[len(got)-1] != "d.log.1" {
+		t.Errorf("newest first = %v", got)
+	}
+
+	entry, ok := a.Entry(filepath.Join(dir, "d.log.1"))
+	if !ok || entry.compression != "gzip" || entry.dir {
+		t.Errorf("Entry() = %+v, %v", entry, ok)
+	}
+	if details := entryDetails(entry); !strings.Contains(details, "gzip") || !strings.Contains(details, old.Add(-time.Hour).Format("2006-01-02 15:04")) {
+		t.Errorf("entryDetails() = %q", details)
+	}
+}
+
+func TestCompleteInput_Async(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "unique.log")
+
+	m := Model{fileInput: textinput.New(), autocomplete: NewAutocomplete(), locationCursor: -1}
+	m.fileInput.SetValue(filepath.Join(dir, "uni"))
+
+	// The directory is read in the background, then the input completed
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = next.(Model)
+	if m.autocomplete.Pending() == "" || cmd == nil || m.fileInput.Value() != filepath.Join(dir, "uni") {
+		t.Fatalf("pending = %q, input = %q", m.autocomplete.Pending(), m.fileInput.Value())
+	}
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if m.autocomplete.Pending() != "" || m.fileInput.Value() != filepath.Join(dir, "unique.log") {
+		t.Fatalf("after listing: pending = %q, input = %q", m.autocomplete.Pending(), m.fileInput.Value())
+	}
+
+	// A cached directory completes right away and is only revalidated
+	m.fileInput.SetValue(filepath.Join(dir, "u"))
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = next.(Model)
+	if m.fileInput.Value() != filepath.Join(dir, "unique.log") {
+		t.Errorf("cached completion = %q", m.fileInput.Value())
+	}
+	if msg := cmd(); msg != nil {
+		t.Errorf("revalidating an unchanged directory = %#v", msg)
+	}
+}
@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/storage"
+)
+
+// location is a bookmark or recently cleaned file listed under an empty
+// path input
+type location struct {
+	path     string
+	bookmark bool
+}
+
+// locations returns the bookmarks followed by the recent files that are
+// not bookmarked
+func (m Model) locations() []location {
+	var locs []location
+	seen := make(map[string]bool)
+	for _, path := range m.bookmarks {
+		locs = append(locs, location{path: path, bookmark: true})
+		seen[path] = true
+	}
+	for _, path := range m.recentFiles {
+		if !seen[path] {
+			locs = append(locs, location{path: path})
+		}
+	}
+	return locs
+}
+
+// loadLocations reads the recent files and bookmarks, dropping those that
+// no longer exist
+func (m *Model) loadLocations() {
+	m.locationErr = m.updateConfig(func(cfg *storage.Config) bool {
+		return cfg.Prune()
+	})
+}
+
+// updateConfig applies change to the saved config, saving it if change
+// reports a modification, and refreshes the listed locations
+func (m *Model) updateConfig(change func(*storage.Config) bool) error {
+	if m.storage == nil {
+		return nil
+	}
+	cfg, err := m.storage.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	changed := change(cfg)
+	m.recentFiles, m.bookmarks = cfg.RecentFiles, cfg.Bookmarks
+	m.locationCursor = min(m.locationCursor, len(m.locations())-1)
+	if !changed {
+		return nil
+	}
+	return m.storage.SaveConfig(cfg)
+}
+
+// addRecent records the input of a finished run as recently cleaned: the
+// file, or the directory a batch was started from
+func (m *Model) addRecent() {
+	path := m.filePath
+	if m.batchFiles != nil {
+		path = m.inputPath()
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return
+		}
+	}
+	abs, err := filepath.Abs(path)
+	if path == "" || err != nil {
+		return
+	}
+
+	m.locationErr = m.updateConfig(func(cfg *storage.Config) bool {
+		cfg.AddRecentFile(abs)
+		return true
+	})
+}
+
+// toggleBookmark bookmarks the entered path, or the selected location if
+// the input is empty, or removes its bookmark
+func (m *Model) toggleBookmark() {
+	path := m.inputPath()
+	if locs := m.locations(); path == "" && m.locationCursor >= 0 && m.locationCursor < len(locs) {
+		path = locs[m.locationCursor].path
+	}
+	if path == "" {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		m.locationErr = fmt.Errorf("cannot bookmark %s: %w", path, err)
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		m.locationErr = err
+		return
+	}
+
+	m.locationErr = m.updateConfig(func(cfg *storage.Config) bool {
+		cfg.ToggleBookmark(abs)
+		return true
+	})
+}
+
+// updateLocations handles the keys of the location list shown under an
+// empty path input and reports whether it handled msg
+func (m Model) updateLocations(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	locs := m.locations()
+	if m.fileInput.Value() != "" || len(locs) == 0 {
+		return m, nil, false
+	}
+
+	switch msg.String() {
+	case "up":
+		m.locationCursor = max(m.locationCursor-1, -1)
+		return m, nil, true
+
+	case "down":
+		m.locationCursor = min(m.locationCursor+1, len(locs)-1)
+		return m, nil, true
+
+	case "delete":
+		if m.locationCursor < 0 {
+			return m, nil, false
+		}
+		path := locs[m.locationCursor].path
+		m.locationErr = m.updateConfig(func(cfg *storage.Config) bool {
+			cfg.Forget(path)
+			return true
+		})
+		return m, nil, true
+
+	case "enter":
+		if m.locationCursor < 0 {
+			return m, nil, false
+		}
+		path := locs[m.locationCursor].path
+		info, err := os.Stat(path)
+		if err != nil {
+			m.loadLocations()
+			if m.locationErr == nil {
+				m.locationErr = fmt.Errorf("%s no longer exists", path)
+			}
+			return m, nil, true
+		}
+
+		// A directory is entered for completing or batch processing; a file
+		// is opened right away
+		if info.IsDir() {
+			path += string(filepath.Separator)
+		}
+		m.fileInput.SetValue(path)
+		m.fileInput.CursorEnd()
+		m.locationCursor = -1
+		m.locationErr = nil
+		m.autocomplete.Reset()
+		m.inspectInput()
+		if info.IsDir() {
+			return m, nil, true
+		}
+		next, cmd := m.updateFileSelect(msg)
+		return next.(Model), cmd, true
+	}
+
+	return m, nil, false
+}
+
+// locationsView lists the bookmarks and recent files
+func (m Model) locationsView() string {
+	var sb strings.Builder
+
+	if m.locationErr != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.locationErr)))
+		sb.WriteString("\n")
+	}
+
+	locs := m.locations()
+	if len(locs) == 0 || m.fileInput.Value() != "" {
+		return sb.String()
+	}
+
+	for i, loc := range locs {
+		if i == 0 && loc.bookmark {
+			sb.WriteString(labelStyle.Render("Bookmarks:"))
+			sb.WriteString("\n")
+		}
+		if !loc.bookmark && (i == 0 || locs[i-1].bookmark) {
+			sb.WriteString(labelStyle.Render("Recent:"))
+			sb.WriteString("\n")
+		}
+
+		display := loc.path
+		if info, err := os.Stat(loc.path); err == nil && info.IsDir() {
+			display += string(filepath.Separator)
+		}
+		if loc.bookmark {
+			display = "★ " + display
+		}
+		if i == m.locationCursor {
+			sb.WriteString(selectedItemStyle.Render("→ " + display))
+		} else {
+			sb.WriteString(dimStyle.Render("  " + display))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(dimStyle.Render("↑/↓: select | Enter: open | Del: remove from list"))
+	sb.WriteString("\n")
+
+	return sb.String()
+}
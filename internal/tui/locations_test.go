@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFileSelect_Locations(t *testing.T) {
+	store := setupStorage(t)
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, []byte("INFO: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := Model{storage: store, fileInput: textinput.New(), autocomplete: NewAutocomplete(), locationCursor: -1}
+	m.filePath = logPath
+	m.addRecent()
+
+	m.fileInput.SetValue(dir)
+	m = press(m, tea.KeyCtrlB)
+	m.fileInput.SetValue("")
+	if cfg, err := store.LoadConfig(); err != nil || len(cfg.Bookmarks) != 1 || len(cfg.RecentFiles) != 1 {
+		t.Fatalf("config = %+v, %v", cfg, err)
+	}
+
+	view := m.locationsView()
+	if !strings.Contains(view, "Bookmarks:") || !strings.Contains(view, "★ "+dir) || !strings.Contains(view, "Recent:") {
+		t.Errorf("view =\n%s", view)
+	}
+
+	// A bookmarked directory is entered into the input
+	m = press(m, tea.KeyDown)
+	m = press(m, tea.KeyEnter)
+	if m.screen != screenFileSelect || m.fileInput.Value() != dir+string(filepath.Separator) {
+		t.Fatalf("screen = %v, input = %q", m.screen, m.fileInput.Value())
+	}
+
+	// A recent file is opened right away
+	m.fileInput.SetValue("")
+	m = press(m, tea.KeyDown)
+	m = press(m, tea.KeyDown)
+	m = press(m, tea.KeyEnter)
+	if m.screen != screenFilterManage || m.filePath != logPath {
+		t.Fatalf("screen = %v, filePath = %q", m.screen, m.filePath)
+	}
+
+	// Entries that no longer exist are dropped
+	if err := os.Remove(logPath); err != nil {
+		t.Fatal(err)
+	}
+	m.loadLocations()
+	if len(m.recentFiles) != 0 || len(m.bookmarks) != 1 {
+		t.Errorf("recent = %v, bookmarks = %v", m.recentFiles, m.bookmarks)
+	}
+
+	m.screen = screenFileSelect
+	m.fileInput.SetValue("")
+	m.locationCursor = 0
+	m = press(m, tea.KeyDelete)
+	if cfg, _ := store.LoadConfig(); len(cfg.Bookmarks) != 0 || len(m.locations()) != 0 {
+		t.Errorf("bookmarks after delete = %v", cfg.Bookmarks)
+	}
+}
@@ -0,0 +1,21 @@
+package tui
+
+import (
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/manifest"
+)
+
+// recordRun hashes the inputs, runs clean and writes the manifest next to
+// output
+func recordRun(mode manifest.Mode, inputs []string, output string, filters []*filter.Filter, recorded manifest.Options, clean func() (*cleaner.Stats, error)) (*cleaner.Stats, error) {
+	m, err := manifest.Begin(mode, inputs, filters, recorded)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := clean()
+	if err != nil {
+		return nil, err
+	}
+	return stats, m.Finish(output, stats)
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/manifest"
+	"github.com/sstreichan/logcleaner/internal/output"
+)
+
+func TestProcess_WritesManifests(t *testing.T) {
+	namer, err := output.NewNamer("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"a.log", "b.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("INFO: "+name+"\nDEBUG: x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, path)
+	}
+	debug, _ := filter.New("debug", "^DEBUG", filter.TypeRemove)
+	filters := []*filter.Filter{debug}
+
+	verify := func(output string) {
+		t.Helper()
+		m, err := manifest.Load(manifest.Path(output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := manifest.Verify(m); err != nil {
+			t.Errorf("Verify(%s) = %v", output, err)
+		}
+	}
+
+	single := Model{namer: namer, filePath: inputs[0], filters: filters}
+	if msg := single.processFile()().(processingMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	verify(inputs[0] + ".cleaned")
+
+	batch := Model{namer: namer, batchFiles: inputs, filters: filters}
+	msg := batch.processBatch()().(batchMsg)
+	for _, res := range msg.result.Files {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		verify(res.OutputPath)
+	}
+
+	merged := Model{namer: namer, batchFiles: inputs, filters: filters, mergeFiles: true, labelLines: true}
+	if msg := merged.processBatch()().(processingMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	verify(merged.outputPath())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -12,10 +13,23 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/discover"
 	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/manifest"
+	"github.com/sstreichan/logcleaner/internal/output"
+	"github.com/sstreichan/logcleaner/internal/report"
+	"github.com/sstreichan/logcleaner/internal/rotation"
 	"github.com/sstreichan/logcleaner/internal/storage"
+	"github.com/sstreichan/logcleaner/internal/textenc"
 )
 
+// windowedDedup is how many distinct lines repeats may be apart in the
+// windowed dedup mode
+const windowedDedup = 100
+
+// resultTemplates is how many message templates the results screen lists
+const resultTemplates = 10
+
 type screen int
 
 const (
@@ -24,6 +38,11 @@ const (
 	screenFilterAdd
 	screenProcessing
 	screenResults
+	screenFollow
+	screenBatchReview
+	screenOutputEdit
+	screenConfirmOverwrite
+	screenHistory
 )
 
 type processingMsg struct {
@@ -32,26 +51,92 @@ type processingMsg struct {
 }
 
 type Model struct {
-	screen       screen
-	fileInput    textinput.Model
-	filePath     string
-	filters      []*filter.Filter
-	storage      *storage.Storage
-	autocomplete *Autocomplete
+	screen     screen
+	fileInput  textinput.Model
+	filePath   string
+	detection  *textenc.Detection
+	inputFiles []string // files named by a directory or glob input
+
+	// Bookmarks and recent files listed under an empty path input;
+	// locationCursor is -1 while none is selected
+	bookmarks      []string
+	recentFiles    []string
+	locationCursor int
+	locationErr    error
+
+	// Fuzzy finder over the files under a directory, nil while closed
+	finder *fileFinder
+
+	// Rotation set of filePath, oldest first, if it has rotated files
+	rotationFiles []string
+	mergeRotation bool
+
+	// In-place cleaning replaces filePath, optionally keeping a backup
+	inPlace bool
+	backup  bool
+
+	// Output naming; outputOverride replaces the name from the template
+	namer          *output.Namer
+	profile        string
+	outputOverride string
+	outputInput    textinput.Model
+	outputErr      error
+	overwrite      []string // existing outputs awaiting confirmation
+	processErr     error    // why the run was refused
+	filters        []*filter.Filter
+	storage        *storage.Storage
+	autocomplete   *Autocomplete
 
 	// Filter management
 	selectedFilter   int
 	newFilterName    textinput.Model
 	newFilterPattern textinput.Model
 	newFilterType    filter.FilterType
-	filterInputFocus int // 0=name, 1=pattern, 2=type
+	newFilterOptions filter.Options
+	optionCursor     int
+	filterInputFocus int // 0=name, 1=pattern, 2=type, 3=options, 4=amount
+	// newFilterAmount is the threshold of frequency filters and the rate
+	// or limit of sample filters
+	newFilterAmount textinput.Model
+	// filterErr explains why the new filter could not be saved
+	filterErr error
+
+	// Pattern tester
+	sample    []string
+	sampleErr error
+	preview   patternPreview
 
 	// Processing
+	preserveEncoding bool
+	dedupWindow      int // 0: off, 1: consecutive repeats only
+	dedupExact       bool
 	processing       bool
 	progressLines    int
 	progressFiltered int
 	stats            *cleaner.Stats
 	err              error
+	templateCursor   int
+	reportPath       string
+	reportErr        error
+
+	// Run history; replay is the filter snapshot of a run repeated from it
+	started       time.Time
+	history       []storage.Run
+	historyCursor int
+	historyErr    error
+	replay        []*filter.Filter
+
+	// Batch mode; filePath is the first file and used for previews
+	batchFiles []string
+	batch      *cleaner.BatchResult
+	mergeFiles bool // interleave batchFiles by timestamp into one output
+	labelLines bool
+
+	// Follow mode
+	follow      *followSession
+	followStats *cleaner.Stats
+	followLines []string
+	followErr   error
 
 	width  int
 	height int
@@ -68,6 +153,15 @@ func NewModel() (*Model, error) {
 		return nil, err
 	}
 
+	config, err := storage.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	namer, err := output.NewNamer(config.OutputTemplate, config.OutputDir, config.Profile)
+	if err != nil {
+		return nil, err
+	}
+
 	fileInput := textinput.New()
 	fileInput.Placeholder = "Enter log file path..."
 	fileInput.Focus()
@@ -82,16 +176,34 @@ func NewModel() (*Model, error) {
 	newFilterPattern.Placeholder = "Regex pattern (e.g. ^ERROR)"
 	newFilterPattern.Width = 40
 
-	return &Model{
+	newFilterAmount := textinput.New()
+	newFilterAmount.Placeholder = "Lines"
+	newFilterAmount.CharLimit = 10
+	newFilterAmount.Width = 20
+
+	outputInput := textinput.New()
+	outputInput.Placeholder = "Output file path"
+	outputInput.CharLimit = 500
+	outputInput.Width = 60
+
+	m := &Model{
 		screen:           screenFileSelect,
 		fileInput:        fileInput,
 		filters:          filters,
 		storage:          storage,
+		namer:            namer,
+		profile:          config.Profile,
+		outputInput:      outputInput,
 		autocomplete:     NewAutocomplete(),
 		newFilterName:    newFilterName,
 		newFilterPattern: newFilterPattern,
 		newFilterType:    filter.TypeRemove,
-	}, nil
+
+		newFilterAmount: newFilterAmount,
+		locationCursor:  -1,
+	}
+	m.loadLocations()
+	return m, nil
 }
 
 func (m Model) Init() tea.Cmd {
@@ -108,12 +220,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.processing = false
 		m.stats = msg.stats
 		m.err = msg.err
+		m.templateCursor = 0
+		m.screen = screenResults
+		m.addHistory(msg.stats, msg.err)
+		if msg.err == nil {
+			m.addRecent()
+		}
+		return m, nil
+
+	case batchMsg:
+		m.processing = false
+		m.batch = msg.result
 		m.screen = screenResults
+		m.addHistory(&msg.result.Total, batchError(msg.result))
+		m.addRecent()
+		return m, nil
+
+	case listingMsg:
+		return m.updateListing(msg)
+
+	case finderMsg:
+		return m.updateScan(msg)
+
+	case followMsg:
+		if msg.session != m.follow {
+			return m, nil
+		}
+		m.followStats = &msg.stats
+		m.followLines = msg.lines
+		return m, waitForFollow(m.follow)
+
+	case followDoneMsg:
+		if msg.session != m.follow {
+			return m, nil
+		}
+		m.follow = nil
+		m.followErr = msg.err
+		if msg.stats != nil {
+			m.followStats = msg.stats
+		}
 		return m, nil
 
 	case tea.KeyMsg:
 		// Global quit keys
 		if msg.String() == "ctrl+c" {
+			if m.follow != nil {
+				m.follow.cancel()
+			}
 			return m, tea.Quit
 		}
 
@@ -127,6 +280,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateFilterAdd(msg)
 		case screenResults:
 			return m.updateResults(msg)
+		case screenFollow:
+			return m.updateFollow(msg)
+		case screenBatchReview:
+			return m.updateBatchReview(msg)
+		case screenOutputEdit:
+			return m.updateOutputEdit(msg)
+		case screenConfirmOverwrite:
+			return m.updateConfirmOverwrite(msg)
+		case screenHistory:
+			return m.updateHistory(msg)
 		}
 	}
 
@@ -134,28 +297,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) updateFileSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.finder != nil {
+		return m.updateFinder(msg)
+	}
+	if next, cmd, ok := m.updateLocations(msg); ok {
+		return next, cmd
+	}
+
 	switch msg.String() {
 	case "q":
 		return m, tea.Quit
 
+	case "ctrl+r":
+		return m.openHistory()
+
+	case "ctrl+b":
+		m.toggleBookmark()
+		return m, nil
+
+	case "ctrl+f":
+		return m.openFinder()
+
 	case "tab":
 		// Handle autocomplete cycling
-		currentValue := m.fileInput.Value()
-		completion := m.autocomplete.Complete(currentValue)
-		
-		if completion != "" && completion != currentValue {
-			m.fileInput.SetValue(completion)
-			m.fileInput.SetCursor(len(completion))
-		}
+		return m.completeInput()
+
+	case "ctrl+t":
+		m.autocomplete.ToggleSort()
 		return m, nil
 
 	case "enter":
 		// Validate and move to next screen
 		if m.fileInput.Value() != "" {
-			if _, err := os.Stat(m.fileInput.Value()); err == nil {
-				m.filePath = m.fileInput.Value()
+			if info, err := os.Stat(m.inputPath()); err == nil && info.Mode().IsRegular() {
+				m.filePath = m.inputPath()
+				m.batchFiles = nil
+				m.rotationFiles, m.mergeRotation = nil, false
+				m.inPlace, m.backup = false, false
+				m.outputOverride = ""
+				if paths, err := rotation.Discover(m.filePath); err == nil && len(paths) > 1 {
+					m.rotationFiles = paths
+				}
+				m.preserveEncoding = false
 				m.screen = screenFilterManage
 				m.autocomplete.Reset()
+			} else if len(m.inputFiles) > 0 {
+				m.filePath = m.inputFiles[0]
+				m.batchFiles = m.inputFiles
+				m.rotationFiles, m.mergeRotation = nil, false
+				m.inPlace, m.backup = false, false
+				m.outputOverride = ""
+				m.detection = nil
+				m.preserveEncoding = false
+				m.screen = screenBatchReview
+				m.autocomplete.Reset()
 			}
 		}
 		return m, nil
@@ -164,26 +359,62 @@ func (m Model) updateFileSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// For all other keys, let textinput handle them
 		// But first, check if this is a typing key (not just navigation)
 		oldValue := m.fileInput.Value()
-		
+
 		var cmd tea.Cmd
 		m.fileInput, cmd = m.fileInput.Update(msg)
-		
+
 		// If the value changed, reset autocomplete
 		if m.fileInput.Value() != oldValue {
 			m.autocomplete.Reset()
+			m.inspectInput()
+			m.locationCursor = -1
+			m.locationErr = nil
 		}
-		
+
 		return m, cmd
 	}
 }
 
+// inputPath returns the path input with environment variables and a
+// leading ~ or ~user expanded
+func (m Model) inputPath() string {
+	path, _ := discover.ExpandPath(m.fileInput.Value())
+	return path
+}
+
+// inspectInput detects the encoding of the file currently entered in the
+// path input, or expands a directory or glob pattern to the files it names
+func (m *Model) inspectInput() {
+	m.detection = nil
+	m.inputFiles = nil
+	path := m.inputPath()
+	info, err := os.Stat(path)
+	if err == nil && info.Mode().IsRegular() {
+		if detection, err := textenc.DetectFile(path); err == nil {
+			m.detection = &detection
+		}
+		return
+	}
+	if (err == nil && info.IsDir()) || discover.IsPattern(path) {
+		m.inputFiles, _ = discover.Expand(path)
+		if m.namer != nil && m.inputFiles != nil {
+			m.inputFiles = m.namer.WithoutOutputs(m.inputFiles)
+		}
+	}
+}
+
 func (m Model) updateFilterManage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.processErr = nil
+
 	switch msg.String() {
 	case "q":
 		return m, tea.Quit
 
 	case "esc":
 		m.screen = screenFileSelect
+		if m.batchFiles != nil {
+			m.screen = screenBatchReview
+		}
 		return m, nil
 
 	case "up", "k":
@@ -200,10 +431,16 @@ func (m Model) updateFilterManage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.screen = screenFilterAdd
 		m.newFilterName.SetValue("")
 		m.newFilterPattern.SetValue("")
+		m.newFilterAmount.SetValue(defaultThreshold)
 		m.newFilterName.Focus()
 		m.newFilterPattern.Blur()
+		m.newFilterAmount.Blur()
 		m.filterInputFocus = 0
 		m.newFilterType = filter.TypeRemove
+		m.newFilterOptions = filter.Options{}
+		m.optionCursor = 0
+		m.sample, m.sampleErr = loadSample(m.filePath, previewSampleLines)
+		m.preview = buildPreview("", m.newFilterOptions, m.sample, previewMaxShown)
 		return m, textinput.Blink
 
 	case "d":
@@ -215,11 +452,64 @@ func (m Model) updateFilterManage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.storage.Save(m.filters)
 		}
 
+	case "e":
+		if m.detection != nil && m.detection.NeedsTranscoding() {
+			m.preserveEncoding = !m.preserveEncoding
+		}
+
+	case "r":
+		if m.rotationFiles != nil && !m.inPlace {
+			m.mergeRotation = !m.mergeRotation
+		}
+
+	case "i":
+		if m.batchFiles == nil && !m.mergeRotation {
+			m.inPlace = !m.inPlace
+			m.backup = m.inPlace
+		}
+
+	case "b":
+		if m.inPlace {
+			m.backup = !m.backup
+		}
+
+	case "o":
+		if !m.inPlace && (m.batchFiles == nil || m.mergeFiles) {
+			return m.editOutput()
+		}
+
+	case "u":
+		switch m.dedupWindow {
+		case 0:
+			m.dedupWindow = 1
+		case 1:
+			m.dedupWindow = windowedDedup
+		default:
+			m.dedupWindow, m.dedupExact = 0, false
+		}
+
+	case "x":
+		if m.dedupWindow > 0 {
+			m.dedupExact = !m.dedupExact
+		}
+
+	case "f":
+		if m.filePath != "" && m.batchFiles == nil && !m.inPlace && m.follow == nil {
+			session, err := startFollow(m.filters, m.dedupOptions(), m.filePath, m.outputPath(), m.detection, m.preserveEncoding)
+			m.screen = screenFollow
+			m.follow = session
+			m.followStats = nil
+			m.followLines = nil
+			m.followErr = err
+			if err != nil {
+				return m, nil
+			}
+			return m, waitForFollow(session)
+		}
+
 	case "enter":
-		if m.filePath != "" {
-			m.screen = screenProcessing
-			m.processing = true
-			return m, m.processFile()
+		if m.batchFiles != nil || m.filePath != "" {
+			return m.process()
 		}
 	}
 
@@ -227,6 +517,8 @@ func (m Model) updateFilterManage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) updateFilterAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.filterErr = nil
+
 	switch msg.String() {
 	case "q":
 		// In filter add screen, 'q' should type 'q', not quit
@@ -238,48 +530,61 @@ func (m Model) updateFilterAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "tab", "shift+tab":
+		fields := m.filterAddFields()
 		if msg.String() == "tab" {
-			m.filterInputFocus = (m.filterInputFocus + 1) % 3
+			m.filterInputFocus = (m.filterInputFocus + 1) % fields
 		} else {
-			m.filterInputFocus = (m.filterInputFocus + 2) % 3
+			m.filterInputFocus = (m.filterInputFocus + fields - 1) % fields
 		}
 
-		if m.filterInputFocus == 0 {
+		m.newFilterName.Blur()
+		m.newFilterPattern.Blur()
+		m.newFilterAmount.Blur()
+		switch m.filterInputFocus {
+		case 0:
 			m.newFilterName.Focus()
-			m.newFilterPattern.Blur()
-		} else if m.filterInputFocus == 1 {
-			m.newFilterName.Blur()
+		case 1:
 			m.newFilterPattern.Focus()
-		} else {
-			m.newFilterName.Blur()
-			m.newFilterPattern.Blur()
+		case 4:
+			m.newFilterAmount.Focus()
 		}
 		return m, textinput.Blink
 
 	case "left", "right":
 		if m.filterInputFocus == 2 {
-			if m.newFilterType == filter.TypeRemove {
-				m.newFilterType = filter.TypeKeep
+			if msg.String() == "right" {
+				m.newFilterType = cycleFilterType(m.newFilterType, 1)
 			} else {
-				m.newFilterType = filter.TypeRemove
+				m.newFilterType = cycleFilterType(m.newFilterType, -1)
+			}
+			return m, nil
+		}
+		if m.filterInputFocus == 3 {
+			if msg.String() == "right" {
+				m.optionCursor = (m.optionCursor + 1) % len(filterOptionLabels)
+			} else {
+				m.optionCursor = (m.optionCursor + len(filterOptionLabels) - 1) % len(filterOptionLabels)
 			}
 			return m, nil
 		}
 		// If not on type selector, let textinput handle left/right
 
+	case " ":
+		if m.filterInputFocus == 3 {
+			toggleFilterOption(&m.newFilterOptions, m.optionCursor)
+			m.preview = buildPreview(strings.TrimSpace(m.newFilterPattern.Value()), m.newFilterOptions, m.sample, previewMaxShown)
+			return m, nil
+		}
+
 	case "enter":
-		name := strings.TrimSpace(m.newFilterName.Value())
-		pattern := strings.TrimSpace(m.newFilterPattern.Value())
-
-		if name != "" && pattern != "" {
-			newFilter, err := filter.New(name, pattern, m.newFilterType)
-			if err == nil {
-				m.filters = append(m.filters, newFilter)
-				m.storage.Save(m.filters)
-				m.screen = screenFilterManage
-				return m, nil
-			}
+		newFilter, err := m.buildNewFilter()
+		if err != nil {
+			m.filterErr = err
+			return m, nil
 		}
+		m.filters = append(m.filters, newFilter)
+		m.storage.Save(m.filters)
+		m.screen = screenFilterManage
 		return m, nil
 	}
 
@@ -288,7 +593,13 @@ func (m Model) updateFilterAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.filterInputFocus == 0 {
 		m.newFilterName, cmd = m.newFilterName.Update(msg)
 	} else if m.filterInputFocus == 1 {
+		oldPattern := m.newFilterPattern.Value()
 		m.newFilterPattern, cmd = m.newFilterPattern.Update(msg)
+		if m.newFilterPattern.Value() != oldPattern {
+			m.preview = buildPreview(strings.TrimSpace(m.newFilterPattern.Value()), m.newFilterOptions, m.sample, previewMaxShown)
+		}
+	} else if m.filterInputFocus == 4 {
+		m.newFilterAmount, cmd = m.newFilterAmount.Update(msg)
 	}
 	return m, cmd
 }
@@ -298,35 +609,148 @@ func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q":
 		return m, tea.Quit
 
+	case "up", "k":
+		if m.templateCursor > 0 {
+			m.templateCursor--
+		}
+
+	case "down", "j":
+		if m.stats != nil && m.templateCursor < len(m.stats.Templates)-1 {
+			m.templateCursor++
+		}
+
+	case "r":
+		if m.stats != nil && m.templateCursor < len(m.stats.Templates) {
+			m.addTemplateFilter(m.stats.Templates[m.templateCursor])
+		}
+
+	case "m":
+		m.exportReport(".md")
+
+	case "h":
+		m.exportReport(".html")
+
 	case "enter", "esc":
 		m.screen = screenFileSelect
 		m.fileInput.SetValue("")
+		m.detection = nil
+		m.inputFiles = nil
+		m.batchFiles = nil
+		m.rotationFiles, m.mergeRotation = nil, false
+		m.batch = nil
+		m.mergeFiles, m.labelLines = false, false
+		m.inPlace, m.backup = false, false
+		m.outputOverride = ""
 		m.stats = nil
 		m.err = nil
+		m.reportPath, m.reportErr = "", nil
+		m.replay, m.historyErr = nil, nil
 		m.autocomplete.Reset()
+		m.loadLocations()
 		return m, nil
 	}
 
 	return m, nil
 }
 
+func (m Model) updateFollow(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		// The session keeps delivering messages until its done message,
+		// which clears m.follow
+		if m.follow != nil {
+			m.follow.cancel()
+		}
+		m.screen = screenFilterManage
+	}
+
+	return m, nil
+}
+
 func (m Model) processFile() tea.Cmd {
 	return func() tea.Msg {
 		time.Sleep(100 * time.Millisecond) // Small delay for UI
 
-		outputPath := m.filePath + ".cleaned"
-		c := cleaner.New(m.filters)
+		outputPath := m.outputPath()
+		opts := m.cleanerOptions()
+		filters := m.activeFilters()
+		c := cleaner.NewWithOptions(filters, opts)
+		recorded := manifest.NewOptions(opts)
+		if err := createOutputDirs([]string{outputPath}); err != nil {
+			return processingMsg{err: err}
+		}
 
-		stats, err := c.Clean(m.filePath, outputPath, func(lines, filtered int) {
-			// Progress callback (could be enhanced with tea.Cmd)
-			m.progressLines = lines
-			m.progressFiltered = filtered
+		if m.mergeRotation {
+			stats, err := recordRun(manifest.ModeRotated, m.rotationFiles, outputPath, filters, recorded, func() (*cleaner.Stats, error) {
+				return cleanRotated(c, m.rotationFiles, outputPath)
+			})
+			return processingMsg{stats: stats, err: err}
+		}
+		if m.inPlace {
+			recorded.Backup = m.backup
+			stats, err := recordRun(manifest.ModeInPlace, []string{m.filePath}, outputPath, filters, recorded, func() (*cleaner.Stats, error) {
+				return c.CleanInPlace(m.filePath, cleaner.InPlaceOptions{Backup: m.backup}, nil)
+			})
+			return processingMsg{stats: stats, err: err}
+		}
+
+		stats, err := recordRun(manifest.ModeClean, []string{m.filePath}, outputPath, filters, recorded, func() (*cleaner.Stats, error) {
+			return c.Clean(m.filePath, outputPath, func(lines, filtered int) {
+				// Progress callback (could be enhanced with tea.Cmd)
+				m.progressLines = lines
+				m.progressFiltered = filtered
+			})
 		})
 
 		return processingMsg{stats: stats, err: err}
 	}
 }
 
+// cleanerOptions returns the options chosen for the current run
+func (m Model) cleanerOptions() cleaner.Options {
+	opts := m.dedupOptions()
+	opts.Workers = runtime.NumCPU()
+	opts.PreserveEncoding = m.preserveEncoding
+	opts.Templates = resultTemplates
+	opts.Histogram = true
+	opts.RemovedSamples = report.Samples
+	return opts
+}
+
+func (m Model) dedupOptions() cleaner.Options {
+	return cleaner.Options{DedupWindow: m.dedupWindow, DedupExact: m.dedupExact}
+}
+
+// outputPath returns where the current run writes its output
+func (m Model) outputPath() string {
+	if m.outputOverride != "" && !m.inPlace {
+		return m.outputOverride
+	}
+	return m.defaultOutputPath()
+}
+
+// defaultOutputPath returns the output path given by the template
+func (m Model) defaultOutputPath() string {
+	if m.mergeFiles {
+		return m.namer.MergedPath(m.batchFiles)
+	}
+	if m.inPlace {
+		return m.filePath
+	}
+	return m.namer.Path(m.filePath)
+}
+
+// cleanRotated merges a rotation set into a single cleaned file
+func cleanRotated(c *cleaner.Cleaner, paths []string, outputPath string) (*cleaner.Stats, error) {
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outFile.Close()
+
+	return c.CleanRotated(paths, outFile, nil)
+}
+
 func (m Model) View() string {
 	switch m.screen {
 	case screenFileSelect:
@@ -339,6 +763,16 @@ func (m Model) View() string {
 		return m.processingView()
 	case screenResults:
 		return m.resultsView()
+	case screenFollow:
+		return m.followView()
+	case screenBatchReview:
+		return m.batchReviewView()
+	case screenOutputEdit:
+		return m.outputEditView()
+	case screenConfirmOverwrite:
+		return m.confirmOverwriteView()
+	case screenHistory:
+		return m.historyView()
 	}
 	return ""
 }
@@ -353,45 +787,87 @@ func (m Model) fileSelectView() string {
 	content.WriteString(m.fileInput.View())
 	content.WriteString("\n\n")
 
+	if m.finder != nil {
+		content.WriteString(m.finderView())
+		help := helpStyle.Render("Type to search | ↑/↓: select | Enter: open | Esc: back to path input | Ctrl+C: quit")
+		return lipgloss.JoinVertical(lipgloss.Left, title, content.String(), help)
+	}
+
 	// Show file validation
 	if m.fileInput.Value() != "" {
-		if _, err := os.Stat(m.fileInput.Value()); err != nil {
+		path, expandErr := discover.ExpandPath(m.fileInput.Value())
+		if expandErr != nil {
+			content.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", expandErr)))
+			content.WriteString("\n")
+		} else if path != m.fileInput.Value() {
+			content.WriteString(dimStyle.Render("= " + path))
+			content.WriteString("\n")
+		}
+
+		info, err := os.Stat(path)
+		if len(m.inputFiles) > 0 {
+			content.WriteString(infoStyle.Render(fmt.Sprintf("✓ %d files match", len(m.inputFiles))))
+			content.WriteString("\n")
+		} else if (err == nil && info.IsDir()) || discover.IsPattern(path) {
+			content.WriteString(errorStyle.Render("⚠ No files match"))
+			content.WriteString("\n")
+		} else if err != nil {
 			content.WriteString(errorStyle.Render("⚠ File not found"))
 			content.WriteString("\n")
 		} else {
 			content.WriteString(infoStyle.Render("✓ File exists"))
 			content.WriteString("\n")
+			if m.detection != nil && m.detection.Binary {
+				content.WriteString(errorStyle.Render("⚠ This looks like a binary file, not a log"))
+				content.WriteString("\n")
+			} else if m.detection != nil && m.detection.NeedsTranscoding() {
+				content.WriteString(errorStyle.Render(fmt.Sprintf("⚠ Encoding %s will be converted to UTF-8 for filtering", m.detection)))
+				content.WriteString("\n")
+			}
 		}
 	}
 
+	content.WriteString(m.locationsView())
+
+	if m.autocomplete.Pending() != "" {
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render("Reading directory..."))
+		content.WriteString("\n")
+	}
+
 	// Show autocomplete suggestions
 	matches := m.autocomplete.GetLastMatches()
 	if len(matches) > 0 {
 		content.WriteString("\n")
-		
+
+		order := "directories first"
+		if m.autocomplete.SortedByTime() {
+			order = "newest first"
+		}
+
 		// Show how many matches there are
 		if len(matches) == 1 {
 			content.WriteString(dimStyle.Render("1 match:"))
 		} else {
 			currentIdx := m.autocomplete.GetCurrentIndex()
-			content.WriteString(dimStyle.Render(fmt.Sprintf("%d matches (showing %d/%d):", len(matches), currentIdx+1, len(matches))))
+			content.WriteString(dimStyle.Render(fmt.Sprintf("%d matches (showing %d/%d, %s):", len(matches), currentIdx+1, len(matches), order)))
 		}
 		content.WriteString("\n")
-		
+
 		// Display up to 10 suggestions
 		maxDisplay := 10
 		currentIdx := m.autocomplete.GetCurrentIndex()
-		
+
 		for i, match := range matches {
 			if i >= maxDisplay {
 				content.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(matches)-maxDisplay)))
 				content.WriteString("\n")
 				break
 			}
-			
+
 			// Extract proper display name
 			displayName := match
-			
+
 			// For directories with trailing separator, remove it to get the name
 			if strings.HasSuffix(match, string(filepath.Separator)) {
 				// Remove trailing separator
@@ -402,12 +878,16 @@ func (m Model) fileSelectView() string {
 				// For files, just use the base name
 				displayName = filepath.Base(match)
 			}
-			
+
 			// If displayName is still empty or just "/", show the full path
 			if displayName == "" || displayName == "/" || displayName == "./" {
 				displayName = match
 			}
-			
+
+			if entry, ok := m.autocomplete.Entry(match); ok {
+				displayName = fmt.Sprintf("%-32s  %s", displayName, entryDetails(entry))
+			}
+
 			// Highlight the currently selected match
 			if i == currentIdx {
 				content.WriteString(selectedItemStyle.Render(fmt.Sprintf("→ %s", displayName)))
@@ -418,7 +898,7 @@ func (m Model) fileSelectView() string {
 		}
 	}
 
-	help := helpStyle.Render("Tab: cycle completions | Enter: continue | Ctrl+C: quit")
+	help := helpStyle.Render("Tab: cycle completions | Ctrl+T: sort by name/time | Ctrl+F: find files | Enter: continue | Ctrl+B: bookmark | Ctrl+R: history | Ctrl+C: quit")
 
 	return lipgloss.JoinVertical(lipgloss.Left, title, content.String(), help)
 }
@@ -428,8 +908,49 @@ func (m Model) filterManageView() string {
 
 	sb.WriteString(titleStyle.Render("🔧 Filter Management"))
 	sb.WriteString("\n\n")
-	sb.WriteString(infoStyle.Render(fmt.Sprintf("File: %s", filepath.Base(m.filePath))))
-	sb.WriteString("\n\n")
+	if m.batchFiles != nil {
+		sb.WriteString(infoStyle.Render(fmt.Sprintf("Files: %d (preview uses %s)", len(m.batchFiles), filepath.Base(m.filePath))))
+	} else {
+		sb.WriteString(infoStyle.Render(fmt.Sprintf("File: %s", filepath.Base(m.filePath))))
+	}
+	sb.WriteString("\n")
+	if m.detection != nil && m.detection.NeedsTranscoding() {
+		outputEncoding := "UTF-8"
+		if m.preserveEncoding {
+			outputEncoding = m.detection.String()
+		}
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("Input encoding: %s | Output encoding: %s (e: toggle)", m.detection, outputEncoding)))
+		sb.WriteString("\n")
+	}
+	if m.rotationFiles != nil {
+		mode := "only this file"
+		if m.mergeRotation {
+			mode = "merged oldest to newest"
+		}
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("Rotation set: %d files, %s (r: toggle)", len(m.rotationFiles), mode)))
+		sb.WriteString("\n")
+	}
+	if m.inPlace {
+		backup := "no backup"
+		if m.backup {
+			backup = "backup as " + filepath.Base(m.filePath) + discover.BackupSuffix
+		}
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ In place: the original file will be replaced, %s (i: off, b: toggle backup)", backup)))
+		sb.WriteString("\n")
+	} else if m.batchFiles == nil {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("Output: %s (o: change, i: clean in place)", m.outputPath())))
+		sb.WriteString("\n")
+	} else if m.mergeFiles {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("Output: %s (o: change)", m.outputPath())))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(dimStyle.Render(m.dedupDescription()))
+	sb.WriteString("\n")
+	if m.processErr != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.processErr)))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
 
 	if len(m.filters) == 0 {
 		sb.WriteString(subtitleStyle.Render("No filters configured yet."))
@@ -451,25 +972,42 @@ func (m Model) filterManageView() string {
 				prefix = "  "
 			}
 
-			var typeIcon string
-			if f.Type == filter.TypeRemove {
-				typeIcon = "🗑️  Remove"
-			} else {
-				typeIcon = "✅ Keep"
+			pattern := f.Pattern
+			if pattern == "" {
+				pattern = "(all lines)"
+			}
+			line := fmt.Sprintf("%s%s [%s]: %s", prefix, f.Name, filterTypeLabel(f), pattern)
+			if opts := f.Options.String(); opts != "" {
+				line += fmt.Sprintf(" (%s)", opts)
 			}
-
-			line := fmt.Sprintf("%s%s [%s]: %s", prefix, f.Name, typeIcon, f.Pattern)
 			sb.WriteString(style.Render(line))
 			sb.WriteString("\n")
 		}
 	}
 
 	sb.WriteString("\n")
-	sb.WriteString(helpStyle.Render("↑/↓: navigate | a: add filter | d: delete | Enter: process | Esc: back | Ctrl+C: quit"))
+	sb.WriteString(helpStyle.Render("↑/↓: navigate | a: add filter | d: delete | Enter: process | f: follow | Esc: back | Ctrl+C: quit"))
 
 	return sb.String()
 }
 
+func (m Model) dedupDescription() string {
+	switch {
+	case m.dedupWindow == 0:
+		return "Repeated lines: kept (u: collapse)"
+	case m.dedupExact:
+		return fmt.Sprintf("Repeated lines: %s collapsed, exact match (u: mode, x: ignore timestamps/IDs)", m.dedupScope())
+	}
+	return fmt.Sprintf("Repeated lines: %s collapsed, ignoring timestamps/IDs (u: mode, x: exact match)", m.dedupScope())
+}
+
+func (m Model) dedupScope() string {
+	if m.dedupWindow == 1 {
+		return "consecutive"
+	}
+	return fmt.Sprintf("within %d lines", m.dedupWindow)
+}
+
 func (m Model) filterAddView() string {
 	var sb strings.Builder
 
@@ -498,7 +1036,12 @@ func (m Model) filterAddView() string {
 	sb.WriteString(patternLabel)
 	sb.WriteString("\n")
 	sb.WriteString(m.newFilterPattern.View())
-	sb.WriteString("\n\n")
+	sb.WriteString("\n")
+	if m.preview.err != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.preview.err)))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
 
 	// Type selector
 	var typeLabel string
@@ -510,20 +1053,103 @@ func (m Model) filterAddView() string {
 	sb.WriteString(typeLabel)
 	sb.WriteString("\n")
 
-	if m.newFilterType == filter.TypeRemove {
-		sb.WriteString(selectedButtonStyle.Render("[Remove]"))
-		sb.WriteString(" ")
-		sb.WriteString(buttonStyle.Render(" Keep "))
-	} else {
-		sb.WriteString(buttonStyle.Render("Remove"))
-		sb.WriteString(" ")
-		sb.WriteString(selectedButtonStyle.Render("[Keep]"))
+	for i, ft := range filterTypes {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		label := strings.ToUpper(string(ft[:1])) + string(ft[1:])
+		if ft == m.newFilterType {
+			sb.WriteString(selectedButtonStyle.Render("[" + label + "]"))
+		} else {
+			sb.WriteString(buttonStyle.Render(" " + label + " "))
+		}
 	}
 
 	sb.WriteString("\n\n")
-	sb.WriteString(dimStyle.Render("Remove: Filter out matching lines | Keep: Only keep matching lines"))
+	sb.WriteString(dimStyle.Render("Remove: Filter out matching lines | Keep: Only keep matching lines | Frequency: Drop matching lines whose shape is too common | Sample: Keep a share of matching lines"))
+	sb.WriteString("\n\n")
+
+	// Match options
+	if m.filterInputFocus == 3 {
+		sb.WriteString(focusedLabelStyle.Render("Options:"))
+	} else {
+		sb.WriteString(labelStyle.Render("Options:"))
+	}
+	sb.WriteString("\n")
+	enabled := filterOptionValues(m.newFilterOptions)
+	for i, label := range filterOptionLabels {
+		box := "[ ]"
+		if enabled[i] {
+			box = "[x]"
+		}
+		option := fmt.Sprintf("%s %s", box, label)
+		if m.filterInputFocus == 3 && i == m.optionCursor {
+			sb.WriteString(selectedItemStyle.Render(option))
+		} else {
+			sb.WriteString(itemStyle.Render(option))
+		}
+		sb.WriteString("  ")
+	}
 	sb.WriteString("\n\n")
-	sb.WriteString(helpStyle.Render("Tab: next field | ←/→: toggle type | Enter: save | Esc: cancel"))
+
+	if label, hint := amountLabel(m.newFilterType); label != "" {
+		if m.filterInputFocus == 4 {
+			sb.WriteString(focusedLabelStyle.Render(label))
+		} else {
+			sb.WriteString(labelStyle.Render(label))
+		}
+		sb.WriteString("\n")
+		sb.WriteString(m.newFilterAmount.View())
+		sb.WriteString("\n")
+		sb.WriteString(dimStyle.Render(hint + "; an empty pattern applies to all lines"))
+		sb.WriteString("\n\n")
+	}
+
+	if m.filterErr != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.filterErr)))
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString(m.previewView())
+	sb.WriteString(helpStyle.Render("Tab: next field | ←/→: toggle type / select option | Space: toggle option | Enter: save | Esc: cancel"))
+
+	return sb.String()
+}
+
+func (m Model) previewView() string {
+	var sb strings.Builder
+
+	sb.WriteString(subtitleStyle.Render("Preview:"))
+	sb.WriteString("\n")
+
+	switch {
+	case m.sampleErr != nil:
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.sampleErr)))
+		sb.WriteString("\n")
+	case m.newFilterPattern.Value() == "" || m.preview.err != nil:
+		sb.WriteString(dimStyle.Render("Enter a valid pattern to see matching lines"))
+		sb.WriteString("\n")
+	default:
+		sb.WriteString(infoStyle.Render(fmt.Sprintf(
+			"%d of %d sample lines match (%d matches)",
+			m.preview.matchedLines, m.preview.sampleSize, m.preview.totalMatches,
+		)))
+		sb.WriteString("\n")
+
+		maxLen := m.width - 8
+		if maxLen < 40 {
+			maxLen = 100
+		}
+		for _, line := range m.preview.shown {
+			sb.WriteString(dimStyle.Render(fmt.Sprintf("%5d ", line.lineNum)))
+			sb.WriteString(highlightSpans(line.text, line.spans, maxLen, matchStyle))
+			sb.WriteString("\n")
+		}
+		if m.preview.matchedLines > len(m.preview.shown) {
+			sb.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", m.preview.matchedLines-len(m.preview.shown))))
+			sb.WriteString("\n")
+		}
+	}
 
 	return sb.String()
 }
@@ -547,6 +1173,10 @@ func (m Model) processingView() string {
 func (m Model) resultsView() string {
 	var sb strings.Builder
 
+	if m.batch != nil {
+		return m.batchResultsView()
+	}
+
 	if m.err != nil {
 		sb.WriteString(titleStyle.Render("❌ Error"))
 		sb.WriteString("\n\n")
@@ -555,7 +1185,7 @@ func (m Model) resultsView() string {
 		sb.WriteString(titleStyle.Render("✅ Complete"))
 		sb.WriteString("\n\n")
 
-		outputPath := m.filePath + ".cleaned"
+		outputPath := m.outputPath()
 
 		// Statistics box
 		statsBox := lipgloss.NewStyle().
@@ -569,20 +1199,163 @@ func (m Model) resultsView() string {
 				"Total Lines:     %d\n"+
 				"Filtered Lines:  %d\n"+
 				"Remaining Lines: %d\n"+
-				"Bytes Processed: %.2f MB\n\n"+
-				"Output: %s",
+				"Bytes Processed: %.2f MB\n",
 			m.stats.TotalLines,
 			m.stats.FilteredLines,
-			m.stats.TotalLines-m.stats.FilteredLines,
+			m.stats.RemainingLines(),
 			float64(m.stats.BytesRead)/(1024*1024),
-			filepath.Base(outputPath),
 		)
+		if m.stats.LongLines > 0 {
+			statsContent += fmt.Sprintf(
+				"Long Lines:      %d (%d truncated, %d dropped)\n",
+				m.stats.LongLines,
+				m.stats.TruncatedLines,
+				m.stats.DroppedLongLines,
+			)
+		}
+		if m.stats.DuplicateLines > 0 {
+			statsContent += fmt.Sprintf("Repeats:         %d collapsed\n", m.stats.DuplicateLines)
+		}
+		if m.stats.SampledOut > 0 {
+			statsContent += fmt.Sprintf("Sampled Out:     %d of the filtered\n", m.stats.SampledOut)
+		}
+		if m.mergeFiles {
+			statsContent += fmt.Sprintf("Merged Files:    %d\n", len(m.batchFiles))
+		}
+		if m.mergeRotation {
+			statsContent += fmt.Sprintf(
+				"Merged Files:    %d (%d overlapping lines skipped)\n",
+				len(m.rotationFiles),
+				m.stats.OverlapLines,
+			)
+		}
+		statsContent += fmt.Sprintf("\nOutput: %s", outputPath)
+		if m.inPlace {
+			statsContent += " (in place)"
+			if m.backup {
+				statsContent += fmt.Sprintf("\nBackup: %s", outputPath+discover.BackupSuffix)
+			}
+		}
+		statsContent += fmt.Sprintf("\nManifest: %s", manifest.Path(outputPath))
 
 		sb.WriteString(statsBox.Render(statsContent))
+		if m.historyErr != nil {
+			sb.WriteString("\n")
+			sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ Run not added to the history: %v", m.historyErr)))
+		}
+
+		if h := m.stats.Histogram; h != nil {
+			for _, view := range []string{m.histogramView(h), m.levelsView(h)} {
+				if view != "" {
+					sb.WriteString("\n\n")
+					sb.WriteString(view)
+				}
+			}
+		}
+		if len(m.stats.Suppressed) > 0 {
+			sb.WriteString("\n\n")
+			sb.WriteString(m.suppressedView())
+		}
+		if len(m.stats.Templates) > 0 {
+			sb.WriteString("\n\n")
+			sb.WriteString(m.templatesView())
+		}
+	}
+
+	if line := m.reportLine(); line != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(line)
 	}
 
 	sb.WriteString("\n\n")
-	sb.WriteString(helpStyle.Render("Enter: process another file | Ctrl+C: quit"))
+	switch {
+	case m.stats != nil && len(m.stats.Templates) > 0:
+		sb.WriteString(helpStyle.Render("↑/↓: select template | r: add remove filter | m/h: Markdown/HTML report | Enter: process another file | Ctrl+C: quit"))
+	case m.stats != nil:
+		sb.WriteString(helpStyle.Render("m/h: Markdown/HTML report | Enter: process another file | Ctrl+C: quit"))
+	default:
+		sb.WriteString(helpStyle.Render("Enter: process another file | Ctrl+C: quit"))
+	}
 
 	return sb.String()
 }
+
+func (m Model) followView() string {
+	var sb strings.Builder
+
+	if m.follow != nil {
+		sb.WriteString(titleStyle.Render("📡 Following"))
+	} else {
+		sb.WriteString(titleStyle.Render("📡 Follow stopped"))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(infoStyle.Render(fmt.Sprintf("File: %s → %s", filepath.Base(m.filePath), m.outputPath())))
+	sb.WriteString("\n\n")
+
+	if m.followErr != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.followErr)))
+		sb.WriteString("\n\n")
+	}
+
+	stats := m.followStats
+	if stats == nil {
+		stats = &cleaner.Stats{}
+	}
+	sb.WriteString(subtitleStyle.Render(fmt.Sprintf(
+		"Lines: %d | Filtered: %d | Remaining: %d",
+		stats.TotalLines, stats.FilteredLines, stats.RemainingLines(),
+	)))
+	sb.WriteString("\n\n")
+
+	for i, f := range m.filters {
+		hits := 0
+		if i < len(stats.FilterHits) {
+			hits = stats.FilterHits[i]
+		}
+		sb.WriteString(itemStyle.Render(fmt.Sprintf("  %-24s %8d", f.Name, hits)))
+		sb.WriteString("\n")
+	}
+	if len(m.filters) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(labelStyle.Render("Recent output:"))
+	sb.WriteString("\n")
+	if len(m.followLines) == 0 {
+		sb.WriteString(dimStyle.Render("  Waiting for new lines..."))
+		sb.WriteString("\n")
+	}
+	maxLen := 100
+	if m.width > 4 {
+		maxLen = m.width - 4
+	}
+	for _, line := range m.followLines {
+		sb.WriteString("  ")
+		sb.WriteString(highlightSpans(line, nil, maxLen, matchStyle))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(helpStyle.Render("Esc: stop following | Ctrl+C: quit"))
+
+	return sb.String()
+}
+
+var filterOptionLabels = []string{"Ignore case", "Whole word", "Literal", "Invert"}
+
+func filterOptionValues(opts filter.Options) []bool {
+	return []bool{opts.IgnoreCase, opts.WholeWord, opts.Literal, opts.Invert}
+}
+
+func toggleFilterOption(opts *filter.Options, index int) {
+	switch index {
+	case 0:
+		opts.IgnoreCase = !opts.IgnoreCase
+	case 1:
+		opts.WholeWord = !opts.WholeWord
+	case 2:
+		opts.Literal = !opts.Literal
+	case 3:
+		opts.Invert = !opts.Invert
+	}
+}
@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/output"
+)
+
+const overwriteMaxShown = 10
+
+func (m Model) updateOutputEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.screen = screenFilterManage
+		m.outputErr = nil
+		return m, nil
+
+	case "enter":
+		path := strings.TrimSpace(m.outputInput.Value())
+		switch {
+		case path == "":
+			// An empty path goes back to the name from the template
+			m.outputOverride = ""
+		case overwritesInput([]string{path}, m.runInputs()):
+			m.outputErr = fmt.Errorf("the output cannot be an input, use i to clean in place")
+			return m, nil
+		default:
+			m.outputOverride = path
+		}
+		m.outputErr = nil
+		m.screen = screenFilterManage
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.outputInput, cmd = m.outputInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateConfirmOverwrite(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.overwrite = nil
+		return m.startProcessing()
+
+	case "n", "esc":
+		m.overwrite = nil
+		m.screen = screenFilterManage
+		if m.replay != nil {
+			m.replay = nil
+			m.screen = screenHistory
+		}
+	}
+
+	return m, nil
+}
+
+// editOutput opens the output path input prefilled with the current path
+func (m Model) editOutput() (tea.Model, tea.Cmd) {
+	m.outputInput.SetValue(m.outputPath())
+	m.outputInput.CursorEnd()
+	m.outputInput.Focus()
+	m.outputErr = nil
+	m.screen = screenOutputEdit
+	return m, textinput.Blink
+}
+
+// process starts the run, asking first if it would overwrite files. Outputs
+// that are an input are refused, as they would be truncated before read.
+func (m Model) process() (tea.Model, tea.Cmd) {
+	m.processErr = nil
+	if !m.inPlace {
+		if out, _, ok := output.Overwritten(m.outputPaths(), m.runInputs()); ok {
+			return m.refuse(fmt.Errorf("the output %s is an input, change it with o or the output template", out))
+		}
+		if a, b, out, ok := output.Collision(m.batchFiles, m.outputPaths()); ok {
+			return m.refuse(fmt.Errorf("%s and %s would both be written to %s", a, b, out))
+		}
+		m.overwrite = output.Existing(m.outputPaths())
+		if len(m.overwrite) > 0 {
+			m.screen = screenConfirmOverwrite
+			return m, nil
+		}
+	}
+	return m.startProcessing()
+}
+
+// refuse reports why a run cannot start, in the history for a re-run
+func (m Model) refuse(err error) (tea.Model, tea.Cmd) {
+	m.processErr = err
+	if m.replay != nil {
+		m.historyErr, m.processErr = err, nil
+		m.replay = nil
+	}
+	return m, nil
+}
+
+func (m Model) startProcessing() (tea.Model, tea.Cmd) {
+	m.screen = screenProcessing
+	m.processing = true
+	m.started = time.Now()
+	if m.batchFiles != nil {
+		return m, m.processBatch()
+	}
+	return m, m.processFile()
+}
+
+// outputPaths returns every file the current run writes
+func (m Model) outputPaths() []string {
+	if m.batchFiles == nil || m.mergeFiles {
+		return []string{m.outputPath()}
+	}
+	paths := make([]string, len(m.batchFiles))
+	for i, file := range m.batchFiles {
+		paths[i] = m.namer.Path(file)
+	}
+	return paths
+}
+
+// runInputs returns every file the current run reads
+func (m Model) runInputs() []string {
+	switch {
+	case m.batchFiles != nil:
+		return m.batchFiles
+	case m.mergeRotation:
+		return m.rotationFiles
+	}
+	return []string{m.filePath}
+}
+
+// createOutputDirs creates the directories the outputs are written to, which
+// a template or output directory may name before they exist
+func createOutputDirs(paths []string) error {
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// overwritesInput reports whether one of outputs is one of inputs
+func overwritesInput(outputs, inputs []string) bool {
+	_, _, ok := output.Overwritten(outputs, inputs)
+	return ok
+}
+
+func (m Model) outputEditView() string {
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render("💾 Output File"))
+	sb.WriteString("\n\n")
+	sb.WriteString(subtitleStyle.Render("Write the cleaned output to:"))
+	sb.WriteString("\n\n")
+	sb.WriteString(m.outputInput.View())
+	sb.WriteString("\n\n")
+	if m.outputErr != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.outputErr)))
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(dimStyle.Render(fmt.Sprintf("Default: %s", m.defaultOutputPath())))
+	sb.WriteString("\n")
+	sb.WriteString(helpStyle.Render("Enter: save (empty: default) | Esc: cancel"))
+
+	return sb.String()
+}
+
+func (m Model) confirmOverwriteView() string {
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render("⚠️  Overwrite Files?"))
+	sb.WriteString("\n\n")
+	if len(m.overwrite) == 1 {
+		sb.WriteString(errorStyle.Render("The output file already exists:"))
+	} else {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("%d of %d output files already exist:", len(m.overwrite), len(m.outputPaths()))))
+	}
+	sb.WriteString("\n\n")
+
+	for i, path := range m.overwrite {
+		if i == overwriteMaxShown {
+			sb.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.overwrite)-overwriteMaxShown)))
+			sb.WriteString("\n")
+			break
+		}
+		sb.WriteString(itemStyle.Render("  " + path))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(helpStyle.Render("y: overwrite | n/Esc: back"))
+
+	return sb.String()
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sstreichan/logcleaner/internal/output"
+)
+
+func TestProcess_ConfirmsOverwrite(t *testing.T) {
+	namer, err := output.NewNamer("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	m := Model{namer: namer, filePath: filepath.Join(dir, "app.log")}
+
+	next, _ := m.process()
+	if got := next.(Model).screen; got != screenProcessing {
+		t.Errorf("screen = %v, want processing without existing output", got)
+	}
+
+	if err := os.WriteFile(m.outputPath(), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	next, _ = m.process()
+	confirm := next.(Model)
+	if confirm.screen != screenConfirmOverwrite || len(confirm.overwrite) != 1 {
+		t.Fatalf("screen = %v, overwrite = %v, want confirmation", confirm.screen, confirm.overwrite)
+	}
+
+	// Cleaning in place replaces the input by design and is not confirmed
+	m.inPlace = true
+	if next, _ := m.process(); next.(Model).screen != screenProcessing {
+		t.Errorf("in place run asked for confirmation")
+	}
+}
+
+func TestProcessFile_CreatesOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "cleaned", "today")
+	namer, err := output.NewNamer("", outDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotated []string
+	for _, name := range []string{"app.log.1", "app.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("INFO: "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rotated = append(rotated, path)
+	}
+
+	m := Model{namer: namer, filePath: rotated[1], rotationFiles: rotated, mergeRotation: true}
+	if msg := m.processFile()().(processingMsg); msg.err != nil {
+		t.Fatalf("rotation merge into a new directory: %v", msg.err)
+	}
+	if _, err := os.Stat(m.outputPath()); err != nil || filepath.Dir(m.outputPath()) != outDir {
+		t.Errorf("output %s: %v", m.outputPath(), err)
+	}
+}
+
+func TestProcess_RefusesInputAsOutput(t *testing.T) {
+	namer, err := output.NewNamer("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"a.log", "b.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("INFO: "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, path)
+	}
+
+	m := Model{namer: namer, filePath: inputs[0], batchFiles: inputs, mergeFiles: true, outputInput: textinput.New(), screen: screenOutputEdit}
+	m.outputInput.SetValue(inputs[1])
+	m = press(m, tea.KeyEnter)
+	if m.outputErr == nil || m.outputOverride != "" {
+		t.Errorf("output edit took an input: err = %v, override = %q", m.outputErr, m.outputOverride)
+	}
+
+	m.outputOverride = inputs[1]
+	next, _ := m.process()
+	m = next.(Model)
+	if m.processErr == nil || m.screen == screenProcessing || m.screen == screenConfirmOverwrite {
+		t.Errorf("screen = %v, err = %v, want the run refused", m.screen, m.processErr)
+	}
+	if !strings.Contains(m.filterManageView(), inputs[1]) {
+		t.Errorf("view lacks the refused output:\n%s", m.filterManageView())
+	}
+
+	// The same goes for the files of a merged rotation set
+	m = Model{namer: namer, filePath: inputs[1], rotationFiles: inputs, mergeRotation: true, outputOverride: inputs[0]}
+	if next, _ := m.process(); next.(Model).processErr == nil {
+		t.Error("a rotated file was accepted as the output")
+	}
+}
+
+func TestProcess_RefusesSharedOutputs(t *testing.T) {
+	dir := t.TempDir()
+	namer, err := output.NewNamer("", filepath.Join(dir, "out"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{filepath.Join(dir, "a", "app.log"), filepath.Join(dir, "b", "app.log")}
+
+	m := Model{namer: namer, filePath: inputs[0], batchFiles: inputs}
+	next, _ := m.process()
+	if m = next.(Model); m.processErr == nil || m.screen == screenProcessing {
+		t.Errorf("screen = %v, err = %v, want the batch refused", m.screen, m.processErr)
+	}
+}
+
+func TestProcess_RefusesTemplateNamingInput(t *testing.T) {
+	dir := t.TempDir()
+	namer, err := output.NewNamer("{dir}/{name}{ext}", dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("INFO: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The input exists as the output, but must not be offered to overwrite
+	m := Model{namer: namer, filePath: path}
+	next, _ := m.process()
+	if m = next.(Model); m.processErr == nil || m.screen == screenConfirmOverwrite {
+		t.Errorf("screen = %v, err = %v, want the run refused", m.screen, m.processErr)
+	}
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/textenc"
+)
+
+const (
+	previewSampleLines = 1000
+	previewMaxShown    = 8
+)
+
+// patternPreview holds the result of testing a pattern against a sample
+type patternPreview struct {
+	err          error
+	sampleSize   int
+	matchedLines int
+	totalMatches int
+	shown        []previewLine
+}
+
+type previewLine struct {
+	lineNum int
+	text    string
+	spans   [][]int
+}
+
+// loadSample reads up to maxLines lines from the start of a file,
+// decoded to UTF-8 the same way the cleaner does
+func loadSample(path string, maxLines int) ([]string, error) {
+	detection, err := textenc.DetectFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sample: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sample: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(textenc.NewDecoder(file, detection))
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	var lines []string
+	for len(lines) < maxLines && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return lines, fmt.Errorf("failed to read sample: %w", err)
+	}
+
+	return lines, nil
+}
+
+// buildPreview compiles pattern with opts and collects matching sample lines
+func buildPreview(pattern string, opts filter.Options, sample []string, maxShown int) patternPreview {
+	preview := patternPreview{sampleSize: len(sample)}
+	if pattern == "" {
+		return preview
+	}
+
+	f, err := filter.NewWithOptions("preview", pattern, filter.TypeRemove, opts)
+	if err != nil {
+		preview.err = err
+		return preview
+	}
+
+	for i, line := range sample {
+		if !f.Matches(line) {
+			continue
+		}
+
+		var spans [][]int
+		if !opts.Invert {
+			spans = f.FindAllIndex(line)
+		}
+
+		preview.matchedLines++
+		preview.totalMatches += len(spans)
+		if len(preview.shown) < maxShown {
+			preview.shown = append(preview.shown, previewLine{lineNum: i + 1, text: line, spans: spans})
+		}
+	}
+
+	return preview
+}
+
+// highlightSpans renders line with the given byte ranges highlighted,
+// cutting the output after maxLen bytes
+func highlightSpans(line string, spans [][]int, maxLen int, style lipgloss.Style) string {
+	if maxLen > 0 && len(line) > maxLen {
+		for maxLen > 0 && !utf8.RuneStart(line[maxLen]) {
+			maxLen--
+		}
+		line = line[:maxLen]
+	}
+
+	var sb strings.Builder
+	pos := 0
+	for _, span := range spans {
+		start, end := span[0], span[1]
+		if start >= len(line) {
+			break
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+		if start == end {
+			continue
+		}
+
+		sb.WriteString(line[pos:start])
+		sb.WriteString(style.Render(line[start:end]))
+		pos = end
+	}
+	sb.WriteString(line[pos:])
+
+	return sb.String()
+}
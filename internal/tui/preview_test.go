@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+func TestLoadSample(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "sample.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := loadSample(path, 3)
+	if err != nil {
+		t.Fatalf("loadSample() error = %v", err)
+	}
+
+	if len(lines) != 3 || lines[2] != "three" {
+		t.Errorf("Expected first 3 lines, got %v", lines)
+	}
+}
+
+func TestBuildPreview(t *testing.T) {
+	sample := []string{
+		"ERROR: disk full, ERROR: retry",
+		"INFO: all good",
+		"ERROR: timeout",
+	}
+
+	preview := buildPreview("ERROR", filter.Options{}, sample, 1)
+	if preview.err != nil {
+		t.Fatalf("Unexpected error: %v", preview.err)
+	}
+
+	if preview.matchedLines != 2 {
+		t.Errorf("Expected 2 matched lines, got %d", preview.matchedLines)
+	}
+
+	if preview.totalMatches != 3 {
+		t.Errorf("Expected 3 matches, got %d", preview.totalMatches)
+	}
+
+	if len(preview.shown) != 1 || preview.shown[0].lineNum != 1 {
+		t.Errorf("Expected only the first matching line to be shown, got %v", preview.shown)
+	}
+}
+
+func TestBuildPreview_Invert(t *testing.T) {
+	sample := []string{"ERROR: disk full", "INFO: all good"}
+
+	preview := buildPreview("error", filter.Options{IgnoreCase: true, Invert: true}, sample, 5)
+	if preview.matchedLines != 1 || preview.shown[0].lineNum != 2 {
+		t.Errorf("Expected only the INFO line to match, got %+v", preview)
+	}
+	if preview.totalMatches != 0 {
+		t.Errorf("Expected no highlighted matches for inverted filter, got %d", preview.totalMatches)
+	}
+}
+
+func TestBuildPreview_InvalidPattern(t *testing.T) {
+	preview := buildPreview("[", filter.Options{}, []string{"line"}, 5)
+
+	if preview.err == nil {
+		t.Error("Expected compile error for invalid pattern")
+	}
+}
+
+func TestHighlightSpans(t *testing.T) {
+	style := lipgloss.NewStyle()
+
+	result := highlightSpans("ERROR: disk full", [][]int{{0, 5}}, 0, style)
+	if result != "ERROR: disk full" {
+		t.Errorf("Expected unchanged text with plain style, got %q", result)
+	}
+
+	result = highlightSpans("ERROR: disk full", [][]int{{12, 16}}, 10, style)
+	if result != "ERROR: dis" {
+		t.Errorf("Expected truncated text, got %q", result)
+	}
+}
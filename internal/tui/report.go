@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/report"
+)
+
+// exportReport writes a report of the finished run next to its output
+func (m *Model) exportReport(ext string) {
+	r := m.runReport()
+	if r == nil {
+		return
+	}
+	m.reportPath = m.reportPathFor(ext)
+	m.reportErr = report.WriteFile(m.reportPath, r)
+}
+
+// runReport describes the finished run, or returns nil if there is none
+func (m Model) runReport() *report.Report {
+	r := &report.Report{Generated: time.Now()}
+
+	switch {
+	case m.batch != nil:
+		r.Stats = &m.batch.Total
+		r.Inputs = report.Stat(m.batchFiles...)
+		var outputs []string
+		for _, res := range m.batch.Files {
+			if res.Err == nil {
+				outputs = append(outputs, res.OutputPath)
+			}
+		}
+		r.Outputs = report.Stat(outputs...)
+
+	case m.stats != nil:
+		r.Stats = m.stats
+		switch {
+		case m.mergeFiles:
+			r.Inputs = report.Stat(m.batchFiles...)
+		case m.mergeRotation:
+			r.Inputs = report.Stat(m.rotationFiles...)
+		case m.inPlace:
+			// The input has been replaced by the output
+			r.Inputs = []report.File{{Path: m.filePath, Size: m.stats.BytesRead}}
+		default:
+			r.Inputs = report.Stat(m.filePath)
+		}
+		r.Outputs = report.Stat(m.outputPath())
+
+	default:
+		return nil
+	}
+
+	r.Filters = runFilters(m.activeFilters(), r.Stats)
+	return r
+}
+
+// reportPathFor returns where the report with extension ext is written:
+// next to the output, or next to the first output of a batch
+func (m Model) reportPathFor(ext string) string {
+	if m.batch != nil {
+		return filepath.Join(filepath.Dir(m.namer.Path(m.batchFiles[0])), "batch.report"+ext)
+	}
+	return m.outputPath() + ".report" + ext
+}
+
+// runFilters returns the filters a run used; filters added from the
+// results screen come after them
+func runFilters(filters []*filter.Filter, stats *cleaner.Stats) []*filter.Filter {
+	if len(filters) > len(stats.FilterHits) {
+		return filters[:len(stats.FilterHits)]
+	}
+	return filters
+}
+
+// reportLine reports where the last report was written
+func (m Model) reportLine() string {
+	switch {
+	case m.reportErr != nil:
+		return errorStyle.Render(fmt.Sprintf("⚠ %v", m.reportErr))
+	case m.reportPath != "":
+		return infoStyle.Render("📄 Report written: " + m.reportPath)
+	}
+	return ""
+}
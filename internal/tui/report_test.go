@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sstreichan/logcleaner/internal/cleaner"
+	"github.com/sstreichan/logcleaner/internal/filter"
+	"github.com/sstreichan/logcleaner/internal/output"
+)
+
+func TestResults_ExportReport(t *testing.T) {
+	namer, err := output.NewNamer("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(inputPath, []byte("INFO: a\nDEBUG: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	debug, _ := filter.New("debug", "^DEBUG", filter.TypeRemove)
+	m := Model{namer: namer, filePath: inputPath, filters: []*filter.Filter{debug}, screen: screenResults}
+	m.stats, err = cleaner.NewWithOptions(m.filters, m.cleanerOptions()).Clean(inputPath, m.outputPath(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A filter added on the results screen was not part of the run
+	extra, _ := filter.New("extra", "INFO", filter.TypeRemove)
+	m.filters = append(m.filters, extra)
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = next.(Model)
+	if m.reportErr != nil || m.reportPath != inputPath+".cleaned.report.md" {
+		t.Fatalf("reportPath = %q, err = %v", m.reportPath, m.reportErr)
+	}
+
+	got, err := os.ReadFile(m.reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "DEBUG: b") || strings.Contains(string(got), "extra") {
+		t.Errorf("report =\n%s", got)
+	}
+	if !strings.Contains(m.resultsView(), "Report written") {
+		t.Error("results should show where the report was written")
+	}
+}
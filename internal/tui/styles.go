@@ -4,49 +4,54 @@ import "github.com/charmbracelet/lipgloss"
 
 var (
 	titleStyle = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#7D56F4")).
-		MarginBottom(1)
+			Bold(true).
+			Foreground(lipgloss.Color("#7D56F4")).
+			MarginBottom(1)
 
 	subtitleStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#AAAAAA"))
+			Foreground(lipgloss.Color("#AAAAAA"))
 
 	helpStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#626262")).
-		MarginTop(1)
+			Foreground(lipgloss.Color("#626262")).
+			MarginTop(1)
 
 	errorStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FF0000")).
-		Bold(true)
+			Foreground(lipgloss.Color("#FF0000")).
+			Bold(true)
 
 	infoStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#00AAFF"))
+			Foreground(lipgloss.Color("#00AAFF"))
 
 	dimStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666"))
+			Foreground(lipgloss.Color("#666666"))
 
 	itemStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF"))
+			Foreground(lipgloss.Color("#FFFFFF"))
 
 	selectedItemStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#7D56F4")).
-		Bold(true)
+				Foreground(lipgloss.Color("#7D56F4")).
+				Bold(true)
 
 	labelStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#AAAAAA"))
+			Foreground(lipgloss.Color("#AAAAAA"))
 
 	focusedLabelStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#7D56F4")).
-		Bold(true)
+				Foreground(lipgloss.Color("#7D56F4")).
+				Bold(true)
+
+	matchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#000000")).
+			Background(lipgloss.Color("#FFD700")).
+			Bold(true)
 
 	buttonStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666")).
-		Background(lipgloss.Color("#333333")).
-		Padding(0, 2)
+			Foreground(lipgloss.Color("#666666")).
+			Background(lipgloss.Color("#333333")).
+			Padding(0, 2)
 
 	selectedButtonStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(lipgloss.Color("#7D56F4")).
-		Bold(true).
-		Padding(0, 2)
+				Foreground(lipgloss.Color("#FFFFFF")).
+				Background(lipgloss.Color("#7D56F4")).
+				Bold(true).
+				Padding(0, 2)
 )
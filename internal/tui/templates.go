@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sstreichan/logcleaner/internal/drain"
+	"github.com/sstreichan/logcleaner/internal/filter"
+)
+
+// templateNameLength bounds the template text used as a filter name
+const templateNameLength = 40
+
+// addTemplateFilter adds a filter removing the lines of tmpl, unless one
+// with the same pattern exists
+func (m *Model) addTemplateFilter(tmpl drain.Template) {
+	if m.hasFilterPattern(tmpl.Pattern()) {
+		return
+	}
+
+	f, err := filter.New("template: "+truncate(tmpl.String(), templateNameLength), tmpl.Pattern(), filter.TypeRemove)
+	if err != nil {
+		return
+	}
+	m.filters = append(m.filters, f)
+	m.storage.Save(m.filters)
+}
+
+func (m Model) hasFilterPattern(pattern string) bool {
+	for _, f := range m.filters {
+		if f.Pattern == pattern && f.Type == filter.TypeRemove {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) templatesView() string {
+	var sb strings.Builder
+
+	sb.WriteString(subtitleStyle.Render("Most frequent message templates of the kept lines:"))
+	sb.WriteString("\n\n")
+
+	maxLen := m.width - 24
+	if maxLen < 40 {
+		maxLen = 80
+	}
+	kept := m.stats.RemainingLines() + m.stats.DuplicateLines
+	for i, tmpl := range m.stats.Templates {
+		text := truncate(tmpl.String(), maxLen)
+
+		share := 0.0
+		if kept > 0 {
+			share = float64(tmpl.Count) * 100 / float64(kept)
+		}
+		mark := " "
+		if m.hasFilterPattern(tmpl.Pattern()) {
+			mark = "✓"
+		}
+
+		line := fmt.Sprintf("%s %8d %5.1f%%  %s", mark, tmpl.Count, share, text)
+		if i == m.templateCursor {
+			sb.WriteString(selectedItemStyle.Render("→ " + line))
+		} else {
+			sb.WriteString(itemStyle.Render("  " + line))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// truncate cuts s after at most maxLen bytes on a rune boundary, marking
+// the cut with an ellipsis
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
+	return s[:maxLen] + "…"
+}
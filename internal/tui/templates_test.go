@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/sstreichan/logcleaner/internal/drain"
+)
+
+func TestTemplateFilter(t *testing.T) {
+	tmpl := drain.Template{Tokens: []string{"GET", "<*>", "took", "<*>", "(cache)"}, Count: 3}
+
+	store := setupStorage(t)
+
+	m := Model{storage: store}
+	m.addTemplateFilter(tmpl)
+	m.addTemplateFilter(tmpl)
+
+	if len(m.filters) != 1 {
+		t.Fatalf("got %d filters, want one per template", len(m.filters))
+	}
+	if saved, err := store.Load(); err != nil || len(saved) != 1 {
+		t.Errorf("saved filters = %v, %v", saved, err)
+	}
+	f := m.filters[0]
+	if f.Name != "template: GET <*> took <*> (cache)" {
+		t.Errorf("Name = %q", f.Name)
+	}
+
+	re := regexp.MustCompile(f.Pattern)
+	if !re.MatchString("GET /a took 3ms (cache)") || re.MatchString("GET /a took 3ms cache") {
+		t.Errorf("Pattern %q does not match exactly the template", f.Pattern)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("größe", 3); got != "gr…" {
+		t.Errorf("truncate() = %q, want the cut before a split rune", got)
+	}
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("truncate() = %q", got)
+	}
+}